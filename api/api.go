@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,9 +33,9 @@ var TransactionAttachmentAllowed = []string{
 }
 
 // Ping is used to check the validity of the credentials we need to interact with the Thunes API.
-func (tc *ThunesClient) Ping() (*pkg.Status, error) {
+func (tc *ThunesClient) Ping(ctx context.Context) (*pkg.Status, error) {
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, "ping", nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, "ping", nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +51,7 @@ func (tc *ThunesClient) Ping() (*pkg.Status, error) {
 
 // ListServices is used to check the available services that we can access with our caller account.
 // The arguements to this method are optional, nil refrences can be passed.
-func (tc *ThunesClient) ListServices(page, perPage *int, countryISOCode *string) ([]pkg.Service, error) {
+func (tc *ThunesClient) ListServices(ctx context.Context, page, perPage *int, countryISOCode *string) ([]pkg.Service, error) {
 	// construct query params if they are present
 	queryParams := make(map[string]string)
 	if page != nil {
@@ -64,7 +65,7 @@ func (tc *ThunesClient) ListServices(page, perPage *int, countryISOCode *string)
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, "v2/money-transfer/services", nil, http.StatusOK, queryParams)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, "v2/money-transfer/services", nil, http.StatusOK, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +81,7 @@ func (tc *ThunesClient) ListServices(page, perPage *int, countryISOCode *string)
 
 // ListPayers provides a list of all available payers for our caller account for the service id provided.
 // The arguements to this method are optional, nil references can be passed.
-func (tc *ThunesClient) ListPayers(page, perPage, serviceID *int, countryISOCode, currency *string) ([]pkg.Payer, error) {
+func (tc *ThunesClient) ListPayers(ctx context.Context, page, perPage, serviceID *int, countryISOCode, currency *string) ([]pkg.Payer, error) {
 	// construct query params if they are present
 	queryParams := make(map[string]string)
 	if page != nil {
@@ -100,7 +101,7 @@ func (tc *ThunesClient) ListPayers(page, perPage, serviceID *int, countryISOCode
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, "v2/money-transfer/payers", nil, http.StatusOK, queryParams)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, "v2/money-transfer/payers", nil, http.StatusOK, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -115,9 +116,9 @@ func (tc *ThunesClient) ListPayers(page, perPage, serviceID *int, countryISOCode
 }
 
 // GetPayerDetails retrives information for a given payer.
-func (tc *ThunesClient) GetPayerDetails(id int) (*pkg.Payer, error) {
+func (tc *ThunesClient) GetPayerDetails(ctx context.Context, id int) (*pkg.Payer, error) {
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, fmt.Sprintf("v2/money-transfer/payers/%d", id), nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, fmt.Sprintf("v2/money-transfer/payers/%d", id), nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -132,9 +133,9 @@ func (tc *ThunesClient) GetPayerDetails(id int) (*pkg.Payer, error) {
 }
 
 // GetPayerRates retrives rates for a given payer.
-func (tc *ThunesClient) GetPayerRates(id int) (*pkg.PayerRates, error) {
+func (tc *ThunesClient) GetPayerRates(ctx context.Context, id int) (*pkg.PayerRates, error) {
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, fmt.Sprintf("v2/money-transfer/payers/%d/rates", id), nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, fmt.Sprintf("v2/money-transfer/payers/%d/rates", id), nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +151,7 @@ func (tc *ThunesClient) GetPayerRates(id int) (*pkg.PayerRates, error) {
 
 // ListCountriesAvailable is used to retrieve the list of countries for all money transfer services available for the caller.
 // The arguements to this method are optional, nil references can be passed.
-func (tc *ThunesClient) ListCountriesAvailable(page, perPage *int) ([]pkg.Country, error) {
+func (tc *ThunesClient) ListCountriesAvailable(ctx context.Context, page, perPage *int) ([]pkg.Country, error) {
 	// construct query params if they are present
 	queryParams := make(map[string]string)
 	if page != nil {
@@ -161,7 +162,7 @@ func (tc *ThunesClient) ListCountriesAvailable(page, perPage *int) ([]pkg.Countr
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, "v2/money-transfer/countries", nil, http.StatusOK, queryParams)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, "v2/money-transfer/countries", nil, http.StatusOK, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +178,7 @@ func (tc *ThunesClient) ListCountriesAvailable(page, perPage *int) ([]pkg.Countr
 
 // BICCodeLookup retrives a list of payers' identifier for a given SWIFT BIC code.
 // In the arguements the swiftBICCode is mandatory while page and perPage are optional.
-func (tc *ThunesClient) BICCodeLookup(swiftBICCode string, page, perPage *int) ([]pkg.Lookup, error) {
+func (tc *ThunesClient) BICCodeLookup(ctx context.Context, swiftBICCode string, page, perPage *int) ([]pkg.Lookup, error) {
 	// construct query params if they are present
 	queryParams := make(map[string]string)
 	if page != nil {
@@ -188,7 +189,7 @@ func (tc *ThunesClient) BICCodeLookup(swiftBICCode string, page, perPage *int) (
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, fmt.Sprintf("v2/money-transfer/lookups/BIC/%s", swiftBICCode), nil, http.StatusOK, queryParams)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, fmt.Sprintf("v2/money-transfer/lookups/BIC/%s", swiftBICCode), nil, http.StatusOK, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +206,7 @@ func (tc *ThunesClient) BICCodeLookup(swiftBICCode string, page, perPage *int) (
 // GetBalances retrives information for all account balances per currency.
 // The arguements to this method are optional, nil references can be passed.
 // Formulae: available = balance - pending + credit_facility
-func (tc *ThunesClient) GetBalances(page, perPage *int) ([]pkg.Balance, error) {
+func (tc *ThunesClient) GetBalances(ctx context.Context, page, perPage *int) ([]pkg.Balance, error) {
 	// construct query params if they are present
 	queryParams := make(map[string]string)
 	if page != nil {
@@ -216,7 +217,7 @@ func (tc *ThunesClient) GetBalances(page, perPage *int) ([]pkg.Balance, error) {
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, "v2/money-transfer/balances", nil, http.StatusOK, queryParams)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, "v2/money-transfer/balances", nil, http.StatusOK, queryParams)
 	if err != nil {
 		return nil, err
 	}
@@ -230,50 +231,69 @@ func (tc *ThunesClient) GetBalances(page, perPage *int) ([]pkg.Balance, error) {
 	return balances, nil
 }
 
-// GetCreditPartyInformation retrieves Beneficiary or ReceivingBusinessInformation information based on account details for a given payer and transaction type.
-// All arguements to the method are mandatory.
-// Beneficiary is returned if the transactionType supplies is "C2C" or "B2C".
-// ReceivingBusinessInformation is returned if the transaction type supplied is "C2B" or "B2B".
-func (tc *ThunesClient) GetCreditPartyInformation(id, transactionType, msisdn string, out interface{}) error {
+// GetCreditPartyInformation retrieves the information of the owner of an account for a given payer and transaction type.
+// All arguements to the method are mandatory. The format of the identifier is checked before it is sent.
+// The Beneficiary of the result is set if the transaction type is C2C or B2C,
+// its ReceivingBusiness if the transaction type is C2B or B2B.
+func (tc *ThunesClient) GetCreditPartyInformation(ctx context.Context, id int, transactionType pkg.TransactionType, identifier pkg.CreditPartyIdentifier) (*pkg.CreditParty, error) {
+	if err := transactionType.Validate(); err != nil {
+		return nil, err
+	}
+	if err := identifier.Validate(); err != nil {
+		return nil, err
+	}
+
 	// construct request body
 	creditPartyInfoReq := pkg.CreditPartyIdentifierRequestWrapper{
-		CreditPartyIdentifier: pkg.CreditPartyIdentifier{
-			MSISDN: msisdn,
-		},
+		CreditPartyIdentifier: identifier,
 	}
 	data, err := json.Marshal(creditPartyInfoReq)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// construct the request
 	dataOut, err := tc.NewRequest(
-		http.MethodGet,
-		fmt.Sprintf("v2/money-transfer/payers/%s/%s/credit-party-information", id, transactionType),
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("v2/money-transfer/payers/%d/%s/credit-party-information", id, transactionType),
 		bytes.NewBuffer(data),
 		http.StatusOK,
 		nil,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// handle the success case response
-	if err = json.Unmarshal(dataOut, &out); err != nil {
-		return err
+	// handle the success case response, whose shape depends on the receiving party
+	info := &pkg.CreditParty{}
+	if transactionType.IsBusinessReceiver() {
+		info.ReceivingBusiness = &pkg.ReceivingBusinessInformation{}
+		err = json.Unmarshal(dataOut, info.ReceivingBusiness)
+	} else {
+		info.Beneficiary = &pkg.Beneficiary{}
+		err = json.Unmarshal(dataOut, info.Beneficiary)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return info, nil
 }
 
 // CreditPartyVerification validates the status of an account for a given payer and transaction type.
-// All arguement to be supplied are mandatory.
-func (tc *ThunesClient) CreditPartyVerification(id int, mssidn, transactionType string) (*pkg.VerificationStatus, error) {
+// All arguement to be supplied are mandatory. The format of the identifier is checked before it is sent.
+func (tc *ThunesClient) CreditPartyVerification(ctx context.Context, id int, transactionType pkg.TransactionType, identifier pkg.CreditPartyIdentifier) (*pkg.VerificationStatus, error) {
+	if err := transactionType.Validate(); err != nil {
+		return nil, err
+	}
+	if err := identifier.Validate(); err != nil {
+		return nil, err
+	}
+
 	// construct the request body
 	verificationStatusReq := pkg.CreditPartyIdentifierRequestWrapper{
-		CreditPartyIdentifier: pkg.CreditPartyIdentifier{
-			MSISDN: mssidn,
-		},
+		CreditPartyIdentifier: identifier,
 	}
 	data, err := json.Marshal(verificationStatusReq)
 	if err != nil {
@@ -282,6 +302,7 @@ func (tc *ThunesClient) CreditPartyVerification(id int, mssidn, transactionType
 
 	// construct the request
 	dataOut, err := tc.NewRequest(
+		ctx,
 		http.MethodPost,
 		fmt.Sprintf("v2/money-transfer/payers/%d/%s/credit-party-verification", id, transactionType),
 		bytes.NewBuffer(data),
@@ -302,17 +323,16 @@ func (tc *ThunesClient) CreditPartyVerification(id int, mssidn, transactionType
 }
 
 // CreateQuotationForSource creates a new quotation for a source value.
-// All arguements to be supplied are mandatory.
-func (tc *ThunesClient) CreateQuotationForSource(sourceAmt int, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode, transactionType, externalID string) (*pkg.Quotation, error) {
+// All arguements to be supplied are mandatory, the externalID also makes the request safe to retry.
+func (tc *ThunesClient) CreateQuotationForSource(ctx context.Context, sourceAmt pkg.Decimal, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode string, transactionType pkg.TransactionType, externalID string) (*pkg.Quotation, error) {
 	// quotation request body
-	amt := int64(sourceAmt)
 	quotationReq := pkg.CreateQuotationRequest{
 		ExternalID:      externalID,
 		PayerID:         payerID,
-		Mode:            "SOURCE_AMOUNT",
+		Mode:            pkg.SourceAmount,
 		TransactionType: transactionType,
 		Source: pkg.Source{
-			Amount:         &amt,
+			Amount:         &sourceAmt,
 			Currency:       sourceCurrency,
 			CountryISOCode: sourceCountryISOCode,
 		},
@@ -322,18 +342,17 @@ func (tc *ThunesClient) CreateQuotationForSource(sourceAmt int, destinationCurre
 		},
 	}
 
-	return tc.createQuotation(&quotationReq)
+	return tc.createQuotation(ctx, &quotationReq)
 }
 
 // CreateQuotationForDestination creates a new quotation for a destination value.
-// All arguements to be supplied are mandatory.
-func (tc *ThunesClient) CreateQuotationForDestination(destinationAmt int, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode, transactionType, externalID string) (*pkg.Quotation, error) {
+// All arguements to be supplied are mandatory, the externalID also makes the request safe to retry.
+func (tc *ThunesClient) CreateQuotationForDestination(ctx context.Context, destinationAmt pkg.Decimal, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode string, transactionType pkg.TransactionType, externalID string) (*pkg.Quotation, error) {
 	// quotation request body
-	amt := int64(destinationAmt)
 	quotationReq := pkg.CreateQuotationRequest{
 		ExternalID:      externalID,
 		PayerID:         payerID,
-		Mode:            "DESTINATION_AMOUNT",
+		Mode:            pkg.DestinationAmount,
 		TransactionType: transactionType,
 		Source: pkg.Source{
 			Amount:         nil,
@@ -342,29 +361,50 @@ func (tc *ThunesClient) CreateQuotationForDestination(destinationAmt int, destin
 		},
 		Destination: pkg.CurrencyAmount{
 			Currency: destinationCurrency,
-			Amount:   &amt,
+			Amount:   &destinationAmt,
 		},
 	}
 
-	return tc.createQuotation(&quotationReq)
+	return tc.createQuotation(ctx, &quotationReq)
 }
 
-func (tc *ThunesClient) createQuotation(quotationReq *pkg.CreateQuotationRequest) (*pkg.Quotation, error) {
+func (tc *ThunesClient) createQuotation(ctx context.Context, quotationReq *pkg.CreateQuotationRequest) (*pkg.Quotation, error) {
+	if err := quotationReq.TransactionType.Validate(); err != nil {
+		return nil, err
+	}
+	if err := quotationReq.Mode.Validate(); err != nil {
+		return nil, err
+	}
+
 	// parse to json
 	data, err := json.Marshal(quotationReq)
 	if err != nil {
 		return nil, err
 	}
 
-	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, "v2/money-transfer/quotations", bytes.NewBuffer(data), http.StatusCreated, nil)
+	// construct the request, the external id makes it safe to replay
+	resp, err := tc.send(ctx, &request{
+		method:         http.MethodPost,
+		url:            "v2/money-transfer/quotations",
+		body:           data,
+		contentType:    "application/json",
+		expectedStatus: http.StatusCreated,
+		replayable:     quotationReq.ExternalID != "",
+	})
 	if err != nil {
+		// an earlier attempt may have created the quotation before its response was lost,
+		// in which case the replay is rejected and the quotation can be looked up instead
+		if resp.attempts > 1 && isClientError(err) {
+			if quotation, lookupErr := tc.GetQuotationByExternalID(ctx, quotationReq.ExternalID); lookupErr == nil && sameQuotation(quotation, quotationReq) {
+				return quotation, nil
+			}
+		}
 		return nil, err
 	}
 
 	// handle the success case response
 	var quotation pkg.Quotation
-	if err = json.Unmarshal(dataOut, &quotation); err != nil {
+	if err = json.Unmarshal(resp.body, &quotation); err != nil {
 		return nil, err
 	}
 
@@ -372,9 +412,9 @@ func (tc *ThunesClient) createQuotation(quotationReq *pkg.CreateQuotationRequest
 }
 
 // GetQuotationByID retrieves information for a given quotation given the quotation id.
-func (tc *ThunesClient) GetQuotationByID(id int) (*pkg.Quotation, error) {
+func (tc *ThunesClient) GetQuotationByID(ctx context.Context, id int) (*pkg.Quotation, error) {
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, fmt.Sprintf("v2/money-transfer/quotations/%d", id), nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, fmt.Sprintf("v2/money-transfer/quotations/%d", id), nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -389,9 +429,9 @@ func (tc *ThunesClient) GetQuotationByID(id int) (*pkg.Quotation, error) {
 }
 
 // GetQuotationByExternalID retrieves information for a given quotation given the quotation externalID.
-func (tc *ThunesClient) GetQuotationByExternalID(externalID string) (*pkg.Quotation, error) {
+func (tc *ThunesClient) GetQuotationByExternalID(ctx context.Context, externalID string) (*pkg.Quotation, error) {
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, fmt.Sprintf("v2/money-transfer/quotations/ext-%s", externalID), nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, fmt.Sprintf("v2/money-transfer/quotations/ext-%s", externalID), nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -407,7 +447,8 @@ func (tc *ThunesClient) GetQuotationByExternalID(externalID string) (*pkg.Quotat
 
 // CreateTransaction create a new transaction with transfer values specified from a given quotation.
 // Either the ID or the externalID of the quotation must be supplied. If both are supplied, the ID will be used.
-func (tc *ThunesClient) CreateTransaction(reqBody *pkg.CreateTransactionRequest, id *int, externalID *string) (*pkg.Transaction, error) {
+// The request is only retried when reqBody carries an ExternalID.
+func (tc *ThunesClient) CreateTransaction(ctx context.Context, reqBody *pkg.CreateTransactionRequest, id *int, externalID *string) (*pkg.Transaction, error) {
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the quotation must be supplied")
 	}
@@ -425,30 +466,69 @@ func (tc *ThunesClient) CreateTransaction(reqBody *pkg.CreateTransactionRequest,
 		return nil, err
 	}
 
-	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, reqURL, bytes.NewBuffer(data), http.StatusCreated, nil)
+	// construct the request, the external id makes it safe to replay
+	replayable := reqBody.ExternalID != nil && *reqBody.ExternalID != ""
+	resp, err := tc.send(ctx, &request{
+		method:         http.MethodPost,
+		url:            reqURL,
+		body:           data,
+		contentType:    "application/json",
+		expectedStatus: http.StatusCreated,
+		replayable:     replayable,
+	})
 	if err != nil {
+		// an earlier attempt may have created the transaction before its response was lost,
+		// in which case the replay is rejected and the transaction can be looked up instead
+		if replayable && resp.attempts > 1 && isClientError(err) {
+			if transaction, ok := tc.recoverTransaction(ctx, *reqBody.ExternalID, id, externalID); ok {
+				return transaction, nil
+			}
+		}
 		return nil, err
 	}
 
 	// handle the success case response
 	var transaction pkg.Transaction
-	if err = json.Unmarshal(dataOut, &transaction); err != nil {
+	if err = json.Unmarshal(resp.body, &transaction); err != nil {
 		return nil, err
 	}
 
 	return &transaction, nil
 }
 
+// recoverTransaction looks up the transaction created by an earlier attempt of CreateTransaction,
+// and checks it was created from the quotation identified by id or quotationExternalID.
+func (tc *ThunesClient) recoverTransaction(ctx context.Context, externalID string, id *int, quotationExternalID *string) (*pkg.Transaction, bool) {
+	transaction, err := tc.GetTransactionInformation(ctx, nil, &externalID)
+	if err != nil {
+		return nil, false
+	}
+
+	var quotation *pkg.Quotation
+	if id != nil {
+		quotation, err = tc.GetQuotationByID(ctx, *id)
+	} else {
+		quotation, err = tc.GetQuotationByExternalID(ctx, *quotationExternalID)
+	}
+	if err != nil || !sameTransaction(transaction, quotation) {
+		return nil, false
+	}
+	return transaction, true
+}
+
 // AddAttachmentToTransaction adds an attachemnt to a given transaction.
 // There is a maximum of 3 files that can be sent per transaction.
 // Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
-func (tc *ThunesClient) AddAttachmentToTransaction(name, transactionAttachmentType pkg.TransactionAttachmentType, file *os.File, id *int, externalID *string) (*pkg.TransactionAttachment, error) {
+func (tc *ThunesClient) AddAttachmentToTransaction(ctx context.Context, name string, transactionAttachmentType pkg.TransactionAttachmentType, file *os.File, id *int, externalID *string) (*pkg.TransactionAttachment, error) {
 	// ensure the transaction ID or externalID is supplied
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
 	}
 
+	if err := transactionAttachmentType.Validate(); err != nil {
+		return nil, err
+	}
+
 	fInfo, err := file.Stat()
 	if err != nil {
 		return nil, err
@@ -474,7 +554,6 @@ func (tc *ThunesClient) AddAttachmentToTransaction(name, transactionAttachmentTy
 	// construct the formdata
 	var reqBody bytes.Buffer
 	form := multipart.NewWriter(&reqBody)
-	defer form.Close()
 
 	// add the "type" field
 	{
@@ -490,7 +569,7 @@ func (tc *ThunesClient) AddAttachmentToTransaction(name, transactionAttachmentTy
 
 	// add the file to the form with "name" field
 	{
-		part, err := form.CreateFormFile(file.Name(), file.Name())
+		part, err := form.CreateFormFile("file", filepath.Base(file.Name()))
 		if err != nil {
 			return nil, err
 		}
@@ -500,28 +579,26 @@ func (tc *ThunesClient) AddAttachmentToTransaction(name, transactionAttachmentTy
 		}
 	}
 
-	// construct the request
-	req, err := http.NewRequest(http.MethodGet, tc.baseUrl+reqURL, &reqBody)
-	if err != nil {
+	// write the closing boundary
+	if err := form.Close(); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", form.FormDataContentType())
-
-	// send the request
-	resp, err := http.DefaultClient.Do(req)
+	// the file is not sent again on failure, the attachment could be added twice
+	resp, err := tc.send(ctx, &request{
+		method:         http.MethodPost,
+		url:            reqURL,
+		body:           reqBody.Bytes(),
+		contentType:    form.FormDataContentType(),
+		expectedStatus: http.StatusOK,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Check the response
-	if resp.StatusCode != http.StatusOK {
-		return nil, respError(resp.Body)
-	}
-
 	// handle the success case response
 	var transactionAttachment pkg.TransactionAttachment
-	if err := json.NewDecoder(resp.Body).Decode(&transactionAttachment); err != nil {
+	if err := json.Unmarshal(resp.body, &transactionAttachment); err != nil {
 		return nil, err
 	}
 
@@ -530,7 +607,7 @@ func (tc *ThunesClient) AddAttachmentToTransaction(name, transactionAttachmentTy
 
 // ConfirmTransaction confirms a previously-created transaction to initiate processing.
 // Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
-func (tc *ThunesClient) ConfirmTransaction(id *int, externalID *string) (*pkg.Transaction, error) {
+func (tc *ThunesClient) ConfirmTransaction(ctx context.Context, id *int, externalID *string) (*pkg.Transaction, error) {
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
 	}
@@ -543,7 +620,7 @@ func (tc *ThunesClient) ConfirmTransaction(id *int, externalID *string) (*pkg.Tr
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, reqURL, nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodPost, reqURL, nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -553,13 +630,14 @@ func (tc *ThunesClient) ConfirmTransaction(id *int, externalID *string) (*pkg.Tr
 	if err = json.Unmarshal(dataOut, &transaction); err != nil {
 		return nil, err
 	}
+	tc.observePayout(&transaction)
 
 	return &transaction, nil
 }
 
 // GetTransactionInformation retrives information about a given transaction.
 // Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
-func (tc *ThunesClient) GetTransactionInformation(id *int, externalID *string) (*pkg.Transaction, error) {
+func (tc *ThunesClient) GetTransactionInformation(ctx context.Context, id *int, externalID *string) (*pkg.Transaction, error) {
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
 	}
@@ -572,7 +650,7 @@ func (tc *ThunesClient) GetTransactionInformation(id *int, externalID *string) (
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, reqURL, nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, reqURL, nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -587,7 +665,7 @@ func (tc *ThunesClient) GetTransactionInformation(id *int, externalID *string) (
 }
 
 // ListTransactionAttachments retrieves a list of all attachments for a given transaction.
-func (tc *ThunesClient) ListTransactionAttachments(id *int, externalID *string) ([]pkg.TransactionAttachment, error) {
+func (tc *ThunesClient) ListTransactionAttachments(ctx context.Context, id *int, externalID *string) ([]pkg.TransactionAttachment, error) {
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
 	}
@@ -600,7 +678,7 @@ func (tc *ThunesClient) ListTransactionAttachments(id *int, externalID *string)
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodGet, reqURL, nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodGet, reqURL, nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -619,7 +697,7 @@ func (tc *ThunesClient) ListTransactionAttachments(id *int, externalID *string)
 //
 // If the action can’t be performed, an error 1007014 (Transaction can not be cancelled) will be returned.
 // Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
-func (tc *ThunesClient) CancelTransaction(id *int, externalID *string) (*pkg.Transaction, error) {
+func (tc *ThunesClient) CancelTransaction(ctx context.Context, id *int, externalID *string) (*pkg.Transaction, error) {
 	if id == nil && externalID == nil {
 		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
 	}
@@ -632,7 +710,7 @@ func (tc *ThunesClient) CancelTransaction(id *int, externalID *string) (*pkg.Tra
 	}
 
 	// construct the request
-	dataOut, err := tc.NewRequest(http.MethodPost, reqURL, nil, http.StatusOK, nil)
+	dataOut, err := tc.NewRequest(ctx, http.MethodPost, reqURL, nil, http.StatusOK, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1,72 +1,201 @@
 package api
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"io"
 	"io/ioutil"
 	"net/http"
-
-	"thunes-client/pkg"
+	"net/url"
+	"time"
 )
 
+// ThunesClient is a client for the Thunes money transfer API.
+// It is safe for concurrent use by multiple goroutines.
 type ThunesClient struct {
-	baseUrl   string
-	apiKey    string
-	apiSecret string
+	baseUrl     string
+	credentials CredentialsProvider
+	userAgent   string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	limiter     *limiter
+	middlewares []Middleware
+	logger      Logger
+	logBodies   bool
+	metrics     MetricsHook
+	// handler is the pipeline every request goes through, see pipeline
+	handler Handler
+
+	// timeout, proxy and tlsConfig are set by WithTimeout, WithProxy and WithTLSConfig, they are applied
+	// to httpClient once every option ran so that WithHTTPClient can come before or after them
+	timeout   *time.Duration
+	proxy     func(*http.Request) (*url.URL, error)
+	tlsConfig *tls.Config
 }
 
-func NewThunesClient(baseUrl, apiKey, apiSecret string) *ThunesClient {
-	return &ThunesClient{
-		baseUrl:   baseUrl,
-		apiKey:    apiKey,
-		apiSecret: apiSecret,
+// NewThunesClient constructs a client authenticating with the given API key and secret.
+// Use WithCredentialsProvider to load them from elsewhere or to rotate them.
+// Without options, requests go to the Preproduction environment with a timeout of DefaultTimeout
+// and are retried according to DefaultRetryPolicy.
+func NewThunesClient(apiKey, apiSecret string, opts ...Option) *ThunesClient {
+	tc := &ThunesClient{
+		baseUrl:     string(Preproduction),
+		credentials: NewStaticProvider(apiKey, apiSecret),
+		userAgent:   DefaultUserAgent,
+		httpClient:  &http.Client{Timeout: DefaultTimeout},
+		retryPolicy: DefaultRetryPolicy,
+		limiter:     newLimiter(),
+	}
+
+	for _, opt := range opts {
+		opt(tc)
 	}
+	tc.configureHTTPClient()
+	tc.handler = tc.pipeline()
+
+	return tc
 }
 
-func (tc *ThunesClient) NewRequest(method, url string, body io.Reader, expectedStatus int, queryParams map[string]string) ([]byte, error) {
-	// construct the request
-	req, err := http.NewRequest(http.MethodGet, tc.baseUrl+url, body)
+// request describes a single call to the Thunes API.
+type request struct {
+	method         string
+	url            string
+	body           []byte
+	contentType    string
+	expectedStatus int
+	queryParams    map[string]string
+
+	// replayable reports whether the request can safely be sent more than once,
+	// either because it is idempotent or because an external id makes a replay detectable.
+	replayable bool
+}
+
+// response is the outcome of a request.
+type response struct {
+	body     []byte
+	header   http.Header
+	attempts int
+}
+
+// NewRequest sends a request to the Thunes API and returns the response body.
+// The request is bound to ctx, cancelling ctx aborts the call in flight.
+// GET requests are retried according to the client's RetryPolicy.
+func (tc *ThunesClient) NewRequest(ctx context.Context, method, url string, body io.Reader, expectedStatus int, queryParams map[string]string) ([]byte, error) {
+	r := &request{
+		method:         method,
+		url:            url,
+		expectedStatus: expectedStatus,
+		queryParams:    queryParams,
+		replayable:     method == http.MethodGet,
+	}
+
+	// buffer the body so that it can be sent again on retries
+	if body != nil {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		r.body = data
+		r.contentType = "application/json"
+	}
+
+	resp, err := tc.send(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 
+	return resp.body, nil
+}
+
+// send performs the request, retrying it if it is replayable and the failure is transient.
+// The returned response is never nil and reports the number of attempts made, even when err is not nil.
+func (tc *ThunesClient) send(ctx context.Context, r *request) (*response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := tc.sendOnce(ctx, r, attempt)
+		resp.attempts = attempt
+		if err == nil || !r.replayable || !isRetryable(err) {
+			return resp, err
+		}
+
+		wait, ok := tc.retryPolicy.backoff(attempt, retryAfter(resp.header))
+		if !ok {
+			return resp, err
+		}
+		if tc.logger != nil {
+			tc.logger.Debug("thunes request retried", "method", r.method, "path", r.url, "attempt", attempt, "wait", wait, "error", err.Error())
+		}
+
+		// wait before the next attempt unless the caller gives up first
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// authorize sets the authentication and the User-Agent headers of req.
+func (tc *ThunesClient) authorize(req *http.Request) error {
+	creds, err := tc.credentials.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(creds.APIKey, creds.APISecret)
+	req.Header.Set("User-Agent", tc.userAgent)
+	return nil
+}
+
+// sendOnce performs a single attempt of the request through the pipeline of the client.
+func (tc *ThunesClient) sendOnce(ctx context.Context, r *request, attempt int) (*response, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	// construct the request
+	ctx = withRequestInfo(ctx, RequestInfo{Endpoint: endpointOf(r.url), Group: endpointGroup(r.url), Attempt: attempt})
+	req, err := http.NewRequestWithContext(ctx, r.method, tc.baseUrl+r.url, body)
+	if err != nil {
+		return &response{}, err
+	}
+
 	// if query params exist, add them to the request
-	if len(queryParams) != 0 {
+	if len(r.queryParams) != 0 {
 		q := req.URL.Query()
-		for key, value := range queryParams {
+		for key, value := range r.queryParams {
 			q.Add(key, value)
 		}
 		req.URL.RawQuery = q.Encode()
 	}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
 	}
 
-	// set the auth
-	req.SetBasicAuth(tc.apiKey, tc.apiSecret)
-
 	// make the http request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := tc.handler.Do(req)
 	if err != nil {
-		return nil, err
+		return &response{}, err
+	}
+	if resp.Body == nil {
+		resp.Body = http.NoBody
 	}
 	defer resp.Body.Close()
 
 	// handle the error response case
-	if resp.StatusCode != expectedStatus {
-		return nil, respError(resp.Body)
+	if resp.StatusCode != r.expectedStatus {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if err != nil {
+			return &response{header: resp.Header}, err
+		}
+		return &response{header: resp.Header}, newAPIError(req, resp.StatusCode, body)
 	}
 
 	// return the bytes from the response if present
-	return ioutil.ReadAll(resp.Body)
-}
-
-func respError(body io.Reader) error {
-	var errors pkg.Errors
-	if err := json.NewDecoder(body).Decode(&errors); err != nil {
-		return err
-	}
-
-	return errors.ToError()
+	data, err := ioutil.ReadAll(resp.Body)
+	return &response{body: data, header: resp.Header}, err
 }
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned by a CredentialsProvider which has no credentials to offer,
+// e.g. because its environment variables are not set or its file does not exist.
+// ChainProvider moves on to the next provider on this error only.
+var ErrNoCredentials = errors.New("thunes: no credentials found")
+
+// ErrInsecureCredentialsFile is returned by a FileProvider whose file can be read by other users.
+var ErrInsecureCredentialsFile = errors.New("thunes: credentials file is accessible by other users")
+
+// Credentials are the API key and secret authenticating requests to the Thunes API.
+//
+// Credentials are never printed: String and GoString redact the secret and all but the
+// first characters of the key, so they can safely end up in logs and error messages.
+type Credentials struct {
+	APIKey    string
+	APISecret string
+}
+
+// String returns a redacted representation of the credentials.
+func (c Credentials) String() string {
+	return fmt.Sprintf("Credentials{APIKey: %s, APISecret: %s}", redactKey(c.APIKey), redactSecret(c.APISecret))
+}
+
+// GoString returns the same redacted representation as String, for the %#v verb.
+func (c Credentials) GoString() string {
+	return c.String()
+}
+
+// valid reports whether both the key and the secret are set.
+func (c Credentials) valid() bool {
+	return c.APIKey != "" && c.APISecret != ""
+}
+
+// redactKey keeps the first four characters of a key, enough to tell two keys apart.
+func redactKey(key string) string {
+	if len(key) <= 8 {
+		return redactSecret(key)
+	}
+	return key[:4] + "****"
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return `""`
+	}
+	return "****"
+}
+
+// CredentialsProvider supplies the credentials of the client.
+//
+// Credentials is called before every request, which lets the credentials be rotated
+// without rebuilding the client. Implementations must be safe for concurrent use and
+// should cache the credentials rather than fetch them on every call.
+// Errors must not contain the credentials themselves.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// WithCredentialsProvider authenticates requests with the credentials supplied by p,
+// the key and secret given to NewThunesClient are then ignored.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return func(tc *ThunesClient) {
+		tc.credentials = p
+	}
+}
+
+// StaticProvider supplies fixed credentials, which can be replaced at any time with Rotate.
+type StaticProvider struct {
+	mu    sync.RWMutex
+	creds Credentials
+}
+
+// NewStaticProvider constructs a provider supplying the given key and secret.
+func NewStaticProvider(apiKey, apiSecret string) *StaticProvider {
+	return &StaticProvider{creds: Credentials{APIKey: apiKey, APISecret: apiSecret}}
+}
+
+func (p *StaticProvider) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.creds.valid() {
+		return Credentials{}, ErrNoCredentials
+	}
+	return p.creds, nil
+}
+
+// Rotate replaces the credentials, requests started afterwards use the new ones.
+func (p *StaticProvider) Rotate(apiKey, apiSecret string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.creds = Credentials{APIKey: apiKey, APISecret: apiSecret}
+}
+
+const (
+	// DefaultKeyEnvVar is the environment variable read by EnvProvider for the API key.
+	DefaultKeyEnvVar = "THUNES_API_KEY"
+	// DefaultSecretEnvVar is the environment variable read by EnvProvider for the API secret.
+	DefaultSecretEnvVar = "THUNES_API_SECRET"
+)
+
+// EnvProvider supplies the credentials found in environment variables.
+// The variables are read on every call, so changing them rotates the credentials.
+type EnvProvider struct {
+	// KeyVar is the variable holding the API key, DefaultKeyEnvVar when empty.
+	KeyVar string
+	// SecretVar is the variable holding the API secret, DefaultSecretEnvVar when empty.
+	SecretVar string
+}
+
+func (p EnvProvider) Credentials(ctx context.Context) (Credentials, error) {
+	keyVar, secretVar := p.KeyVar, p.SecretVar
+	if keyVar == "" {
+		keyVar = DefaultKeyEnvVar
+	}
+	if secretVar == "" {
+		secretVar = DefaultSecretEnvVar
+	}
+
+	creds := Credentials{APIKey: os.Getenv(keyVar), APISecret: os.Getenv(secretVar)}
+	if !creds.valid() {
+		return Credentials{}, fmt.Errorf("%w: %s and %s must be set", ErrNoCredentials, keyVar, secretVar)
+	}
+	return creds, nil
+}
+
+// FileProvider supplies the credentials stored in a JSON file:
+//
+//	{
+//		"api_key": "...",
+//		"api_secret": "..."
+//	}
+//
+// Other fields of the file are ignored, so it can be shared with other settings.
+// The file is read again whenever it is modified, so rewriting it rotates the credentials.
+//
+// On Unix systems, the file is rejected with ErrInsecureCredentialsFile when its permissions
+// give any access to the group or to other users: it should be chmod 600.
+type FileProvider struct {
+	path string
+
+	mu      sync.Mutex
+	creds   Credentials
+	modTime time.Time
+	size    int64
+}
+
+// NewFileProvider constructs a provider reading the credentials from the file at path.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, fmt.Errorf("%w: %s does not exist", ErrNoCredentials, p.path)
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return Credentials{}, fmt.Errorf("%w: %s has mode %s, it should be 0600", ErrInsecureCredentialsFile, p.path, info.Mode().Perm())
+	}
+
+	// reuse the credentials read last time unless the file changed since
+	if p.creds.valid() && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.creds, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var file struct {
+		APIKey    string `json:"api_key"`
+		APISecret string `json:"api_secret"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		// the error of the decoder may quote the content of the file, leave it out
+		return Credentials{}, fmt.Errorf("thunes: credentials file %s is not valid JSON", p.path)
+	}
+
+	creds := Credentials{APIKey: file.APIKey, APISecret: file.APISecret}
+	if !creds.valid() {
+		return Credentials{}, fmt.Errorf("%w: api_key and api_secret must be set in %s", ErrNoCredentials, p.path)
+	}
+
+	p.creds, p.modTime, p.size = creds, info.ModTime(), info.Size()
+	return creds, nil
+}
+
+// ChainProvider supplies the credentials of the first of its providers which has some.
+// A provider returning ErrNoCredentials is skipped, any other error is returned as is:
+// a credentials file with insecure permissions is reported rather than silently ignored.
+type ChainProvider []CredentialsProvider
+
+// NewChainProvider constructs a provider trying each of the given providers in turn.
+func NewChainProvider(providers ...CredentialsProvider) ChainProvider {
+	return ChainProvider(providers)
+}
+
+func (c ChainProvider) Credentials(ctx context.Context) (Credentials, error) {
+	for _, p := range c {
+		creds, err := p.Credentials(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return Credentials{}, err
+		}
+	}
+	return Credentials{}, ErrNoCredentials
+}
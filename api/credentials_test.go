@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+)
+
+// writeCredentials writes a credentials file with the given mode and modification time.
+func writeCredentials(t *testing.T, path, content string, mode os.FileMode, modTime time.Time) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCredentialsRedacted(t *testing.T) {
+	creds := api.Credentials{APIKey: "0123456789abcdef", APISecret: "s3cret"}
+	for _, s := range []string{creds.String(), fmt.Sprintf("%v", creds), fmt.Sprintf("%+v", creds), fmt.Sprintf("%#v", creds)} {
+		if strings.Contains(s, "s3cret") || strings.Contains(s, "456789") {
+			t.Errorf("%s holds the credentials", s)
+		}
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	p := api.NewFileProvider(path)
+	ctx := context.Background()
+
+	if _, err := p.Credentials(ctx); !errors.Is(err, api.ErrNoCredentials) {
+		t.Errorf("Credentials() of a missing file error = %v, want ErrNoCredentials", err)
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	writeCredentials(t, path, `{"api_key": "key-1", "api_secret": "secret-1", "base_url": "ignored"}`, 0600, modTime)
+	creds, err := p.Credentials(ctx)
+	if err != nil || creds.APIKey != "key-1" || creds.APISecret != "secret-1" {
+		t.Fatalf("Credentials() = %v, %v, want key-1", creds, err)
+	}
+
+	// rewriting the file rotates the credentials
+	writeCredentials(t, path, `{"api_key": "key-2", "api_secret": "secret-2"}`, 0600, modTime.Add(time.Minute))
+	if creds, err = p.Credentials(ctx); err != nil || creds.APIKey != "key-2" {
+		t.Errorf("Credentials() after a rewrite = %v, %v, want key-2", creds, err)
+	}
+
+	writeCredentials(t, path, `{"api_key": "key-3", "api_secret": "secret-3`, 0600, modTime.Add(2*time.Minute))
+	if _, err = p.Credentials(ctx); err == nil || strings.Contains(err.Error(), "secret-3") {
+		t.Errorf("Credentials() of invalid JSON error = %v, want an error without the content", err)
+	}
+
+	writeCredentials(t, path, `{"api_key": "key-4"}`, 0600, modTime.Add(3*time.Minute))
+	if _, err = p.Credentials(ctx); !errors.Is(err, api.ErrNoCredentials) {
+		t.Errorf("Credentials() without a secret error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestFileProviderPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+
+	for _, mode := range []os.FileMode{0640, 0604, 0660, 0666} {
+		t.Run(mode.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials.json")
+			writeCredentials(t, path, `{"api_key": "key-1", "api_secret": "secret-1"}`, mode, time.Now())
+
+			_, err := api.NewFileProvider(path).Credentials(context.Background())
+			if !errors.Is(err, api.ErrInsecureCredentialsFile) {
+				t.Errorf("Credentials() error = %v, want ErrInsecureCredentialsFile", err)
+			}
+		})
+	}
+
+	// credentials read while the file was private are not served once it is not
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, `{"api_key": "key-1", "api_secret": "secret-1"}`, 0400, time.Now())
+	p := api.NewFileProvider(path)
+	if _, err := p.Credentials(context.Background()); err != nil {
+		t.Fatalf("Credentials() of a 0400 file error = %v", err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Credentials(context.Background()); !errors.Is(err, api.ErrInsecureCredentialsFile) {
+		t.Errorf("Credentials() after a chmod error = %v, want ErrInsecureCredentialsFile", err)
+	}
+}
+
+func TestChainProvider(t *testing.T) {
+	dir := t.TempDir()
+	unset := api.EnvProvider{KeyVar: "THUNES_TEST_UNSET_KEY", SecretVar: "THUNES_TEST_UNSET_SECRET"}
+	missing := api.NewFileProvider(filepath.Join(dir, "missing.json"))
+	static := api.NewStaticProvider("static-key", "static-secret")
+
+	creds, err := api.NewChainProvider(unset, missing, static).Credentials(context.Background())
+	if err != nil || creds.APIKey != "static-key" {
+		t.Errorf("Credentials() = %v, %v, want the static credentials", creds, err)
+	}
+
+	t.Setenv("THUNES_TEST_KEY", "env-key")
+	t.Setenv("THUNES_TEST_SECRET", "env-secret")
+	env := api.EnvProvider{KeyVar: "THUNES_TEST_KEY", SecretVar: "THUNES_TEST_SECRET"}
+	if creds, err = api.NewChainProvider(unset, env, static).Credentials(context.Background()); err != nil || creds.APIKey != "env-key" {
+		t.Errorf("Credentials() = %v, %v, want the first credentials found", creds, err)
+	}
+
+	if _, err = api.NewChainProvider(unset, missing).Credentials(context.Background()); !errors.Is(err, api.ErrNoCredentials) {
+		t.Errorf("Credentials() of empty providers error = %v, want ErrNoCredentials", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		// an insecure file is reported rather than skipped
+		insecure := filepath.Join(dir, "insecure.json")
+		writeCredentials(t, insecure, `{"api_key": "file-key", "api_secret": "file-secret"}`, 0644, time.Now())
+		if _, err = api.NewChainProvider(api.NewFileProvider(insecure), static).Credentials(context.Background()); !errors.Is(err, api.ErrInsecureCredentialsFile) {
+			t.Errorf("Credentials() error = %v, want ErrInsecureCredentialsFile", err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"thunes-client/pkg"
+)
+
+// Thunes error codes the sentinel errors below are matched against.
+// Any other code can be checked with APIError.HasCode.
+const (
+	CodeInvalidCredentials          = "1000401"
+	CodeQuotationExpired            = "1006003"
+	CodeInsufficientBalance         = "1007002"
+	CodeDuplicateQuotationID        = "1006001"
+	CodeDuplicateTransactionID      = "1007001"
+	CodeTransactionCannotBeCanceled = "1007014"
+)
+
+// Sentinel errors for common Thunes failures, usable with errors.Is on any error returned by the client.
+var (
+	ErrInvalidCredentials          = errors.New("thunes: invalid credentials")
+	ErrQuotationExpired            = errors.New("thunes: quotation expired")
+	ErrInsufficientBalance         = errors.New("thunes: insufficient balance")
+	ErrDuplicateExternalID         = errors.New("thunes: external id already used")
+	ErrTransactionCannotBeCanceled = errors.New("thunes: transaction can not be cancelled")
+)
+
+// sentinelCodes maps every sentinel error to the Thunes error codes it stands for.
+var sentinelCodes = map[error][]string{
+	ErrInvalidCredentials:          {CodeInvalidCredentials},
+	ErrQuotationExpired:            {CodeQuotationExpired},
+	ErrInsufficientBalance:         {CodeInsufficientBalance},
+	ErrDuplicateExternalID:         {CodeDuplicateQuotationID, CodeDuplicateTransactionID},
+	ErrTransactionCannotBeCanceled: {CodeTransactionCannotBeCanceled},
+}
+
+// maxErrorBody is the number of bytes of an error response body kept on an APIError.
+const maxErrorBody = 64 * 1024
+
+// APIError is returned when the Thunes API answers with an unexpected status code.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+
+	// Errors is the list of errors reported by Thunes, it is empty when the body
+	// was not a Thunes error document, e.g. an HTML page served by a proxy.
+	Errors []pkg.Error
+
+	// Body is the raw response body, truncated to 64KB.
+	Body []byte
+}
+
+// newAPIError builds the error for an unexpected response from its raw body.
+func newAPIError(req *http.Request, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		Body:       body,
+	}
+
+	// a body which is not a Thunes error document is kept raw only
+	var errs pkg.Errors
+	if err := json.Unmarshal(body, &errs); err == nil {
+		apiErr.Errors = errs.Errors
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "thunes: %s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+
+	if len(e.Errors) > 0 {
+		for i, err := range e.Errors {
+			sep := ";"
+			if i == 0 {
+				sep = ":"
+			}
+			fmt.Fprintf(&b, "%s %s: %s", sep, err.Code, err.Message)
+		}
+		return b.String()
+	}
+
+	// give a glimpse of any other body, e.g. the title of an HTML error page
+	if snippet := bodySnippet(e.Body, 200); snippet != "" {
+		fmt.Fprintf(&b, ": %s", snippet)
+	}
+
+	return b.String()
+}
+
+// Is reports whether the error matches one of the sentinel errors of this package.
+func (e *APIError) Is(target error) bool {
+	if target == ErrInvalidCredentials && e.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	if target == ErrDuplicateExternalID && e.StatusCode == http.StatusConflict {
+		return true
+	}
+
+	for _, code := range sentinelCodes[target] {
+		if e.HasCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasCode reports whether Thunes returned the given error code.
+func (e *APIError) HasCode(code string) bool {
+	for _, err := range e.Errors {
+		if err.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes returns the Thunes error codes of the response.
+func (e *APIError) Codes() []string {
+	codes := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		codes = append(codes, err.Code)
+	}
+	return codes
+}
+
+// isClientError reports whether err is a 4xx answer of the Thunes API.
+func isClientError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// isNotFound reports whether err is a 404 answer of the Thunes API.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// bodySnippet returns the body collapsed on a single line and cut to at most n runes.
+func bodySnippet(body []byte, n int) string {
+	if !utf8.Valid(body) {
+		return ""
+	}
+
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if utf8.RuneCountInString(snippet) > n {
+		snippet = string([]rune(snippet)[:n]) + "..."
+	}
+
+	return snippet
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// Logger receives the logs of a client. Its methods take a message followed by alternating keys and values,
+// like the ones of log/slog, so that a structured logger can be plugged with a thin adapter.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// maxLoggedBody is the number of bytes of a body logged by WithBodyLogging.
+const maxLoggedBody = 64 * 1024
+
+// WithLogger logs every request sent to logger, with its method, path, endpoint group, attempt, status, latency
+// and the Thunes error codes of the failures. Successful requests are logged at the Info level, 4xx responses
+// at the Warn level, 5xx responses and transport errors at the Error level. Nothing is logged by default.
+//
+// The requests are logged as they are sent, after the middlewares and the rate limits. The headers,
+// and with them the authentication, are never logged.
+func WithLogger(logger Logger) Option {
+	return func(tc *ThunesClient) {
+		tc.logger = logger
+	}
+}
+
+// WithBodyLogging logs the request and response bodies as well, truncated to 64KB. The personal data of
+// senders, beneficiaries, businesses and credit party identifiers is masked, see pkg.RedactJSON, and the
+// bodies which are not JSON, e.g. attachments, are only logged by their size. It requires WithLogger.
+func WithBodyLogging() Option {
+	return func(tc *ThunesClient) {
+		tc.logBodies = true
+	}
+}
+
+// logRequests logs the requests sent by next.
+func (tc *ThunesClient) logRequests(next Handler) Handler {
+	return HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		args := []interface{}{"method", req.Method, "path", req.URL.Path}
+		if info, ok := RequestInfoFrom(req); ok {
+			args = append(args, "group", string(info.Group), "attempt", info.Attempt)
+		}
+		if tc.logBodies && req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := ioutil.ReadAll(io.LimitReader(body, maxLoggedBody))
+				body.Close()
+				args = append(args, "request_body", loggedBody(req.Header.Get("Content-Type"), data))
+			}
+		}
+
+		start := time.Now()
+		resp, err := next.Do(req)
+		args = append(args, "latency", time.Since(start))
+		if err != nil {
+			tc.logger.Error("thunes request failed", append(args, "error", err.Error())...)
+			return nil, err
+		}
+		args = append(args, "status", resp.StatusCode)
+
+		failed := resp.StatusCode >= 300
+		if failed || tc.logBodies {
+			data, err := peekBody(resp, maxLoggedBody)
+			if err != nil {
+				tc.logger.Error("thunes request failed", append(args, "error", err.Error())...)
+				return resp, nil
+			}
+
+			if codes := errorCodes(data); failed && codes != nil {
+				args = append(args, "error_codes", codes)
+			}
+			if tc.logBodies {
+				args = append(args, "response_body", loggedBody(resp.Header.Get("Content-Type"), data))
+			}
+		}
+
+		switch {
+		case resp.StatusCode >= 500:
+			tc.logger.Error("thunes request", args...)
+		case failed:
+			tc.logger.Warn("thunes request", args...)
+		default:
+			tc.logger.Info("thunes request", args...)
+		}
+		return resp, nil
+	})
+}
+
+// peekBody reads up to n bytes of the response body, and puts them back for the caller.
+func peekBody(resp *http.Response, n int64) ([]byte, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, n))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+	return data, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// loggedBody returns the body as logged: the JSON bodies with their personal data masked, the size of the others.
+func loggedBody(contentType string, data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || mediaType == "" {
+		if redacted, err := pkg.RedactJSON(data); err == nil {
+			return string(redacted)
+		}
+	}
+	if contentType == "" {
+		return fmt.Sprintf("<%d bytes>", len(data))
+	}
+	return fmt.Sprintf("<%d bytes of %s>", len(data), contentType)
+}
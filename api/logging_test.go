@@ -0,0 +1,200 @@
+package api_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+type logEntry struct {
+	level, msg string
+	args       []interface{}
+}
+
+// testLogger keeps the entries logged by a client.
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) log(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, args: args})
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+func (l *testLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args) }
+
+// String returns everything logged, as a text handler would write it.
+func (l *testLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b strings.Builder
+	for _, e := range l.entries {
+		fmt.Fprintf(&b, "%s %s", e.level, e.msg)
+		for _, arg := range e.args {
+			fmt.Fprintf(&b, " %v", arg)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// values returns the values logged under key, in order.
+func (l *testLogger) values(key string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var values []string
+	for _, e := range l.entries {
+		for i := 0; i+1 < len(e.args); i += 2 {
+			if e.args[i] == key {
+				values = append(values, fmt.Sprint(e.args[i+1]))
+			}
+		}
+	}
+	return values
+}
+
+func TestLoggingMasksPersonalData(t *testing.T) {
+	srv := thunestest.NewServer()
+	defer srv.Close()
+	logger := &testLogger{}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithLogger(logger), api.WithBodyLogging())
+
+	invoice := filepath.Join(t.TempDir(), "invoice.pdf")
+	if err := ioutil.WriteFile(invoice, []byte("%PDF-1.4 invoice of Jane Wanjiru"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	req := payoutRequest("payout-1")
+	req.Attachments = []api.PayoutAttachment{{Name: "invoice.pdf", Type: pkg.INVOICE, File: f}}
+	if _, err := tc.SendMoney(context.Background(), req); err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+
+	out := logger.String()
+	credentials := base64.StdEncoding.EncodeToString([]byte(thunestest.APIKey + ":" + thunestest.APISecret))
+	for _, secret := range []string{thunestest.APISecret, credentials, "Authorization", "Basic ", "Wanjiru", "254700000001", "Doe", "%PDF"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("the log holds %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, pkg.Redacted) {
+		t.Errorf("the log holds no masked value:\n%s", out)
+	}
+
+	bodies := strings.Join(logger.values("request_body"), "\n")
+	if !strings.Contains(bodies, "bytes of multipart/form-data") {
+		t.Errorf("request bodies = %s, want the attachment logged by its size", bodies)
+	}
+	if !strings.Contains(bodies, `"external_id":"payout-1"`) {
+		t.Errorf("request bodies = %s, want the fields which are not personal", bodies)
+	}
+}
+
+// roundTripFunc answers the requests of a client without a server.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLoggingUnreadableBodies(t *testing.T) {
+	truncated := `{"sender": {"lastname": "Doe"}, "padding": "` + strings.Repeat("x", 70*1024) + `"}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{name: "truncated JSON", contentType: "application/json", body: truncated, want: "<65536 bytes of application/json>"},
+		{name: "HTML", contentType: "text/html; charset=utf-8", body: "<html>Doe</html>", want: "<16 bytes of text/html; charset=utf-8>"},
+		{name: "no content type", body: "Doe Wanjiru", want: "<11 bytes>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorized := false
+			transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				_, _, authorized = req.BasicAuth()
+				header := http.Header{}
+				if tt.contentType != "" {
+					header.Set("Content-Type", tt.contentType)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       ioutil.NopCloser(strings.NewReader(tt.body)),
+					Request:    req,
+				}, nil
+			})
+
+			logger := &testLogger{}
+			tc := api.NewThunesClient("key", "s3cret",
+				api.WithBaseURL("http://thunes.test"),
+				api.WithHTTPClient(&http.Client{Transport: transport}),
+				api.WithRetryPolicy(api.NoRetry),
+				api.WithLogger(logger),
+				api.WithBodyLogging(),
+			)
+			_, _ = tc.AllBalances(context.Background())
+
+			if !authorized {
+				t.Fatal("the request was not authenticated")
+			}
+			bodies := logger.values("response_body")
+			if len(bodies) != 1 || bodies[0] != tt.want {
+				t.Errorf("response bodies = %q, want %q", bodies, tt.want)
+			}
+			if out := logger.String(); strings.Contains(out, "Doe") || strings.Contains(out, "s3cret") {
+				t.Errorf("the log holds personal data or the secret:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestLoggingLevels(t *testing.T) {
+	srv := thunestest.NewServer()
+	defer srv.Close()
+	logger := &testLogger{}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithLogger(logger))
+
+	if _, err := tc.AllBalances(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.GetQuotationByID(context.Background(), 42); err == nil {
+		t.Fatal("GetQuotationByID() of an unknown quotation succeeded")
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.entries) != 2 || logger.entries[0].level != "info" || logger.entries[1].level != "warn" {
+		t.Fatalf("entries = %+v, want an info then a warn entry", logger.entries)
+	}
+	for _, arg := range logger.entries[0].args {
+		if arg == "response_body" || arg == "request_body" {
+			t.Error("bodies were logged without WithBodyLogging")
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// Endpoint names an endpoint of the Thunes API in metrics, whatever the ids in its url.
+type Endpoint string
+
+const (
+	EndpointPing         = Endpoint("ping")
+	EndpointServices     = Endpoint("services")
+	EndpointPayers       = Endpoint("payers")
+	EndpointRates        = Endpoint("rates") // rates of a payer
+	EndpointCountries    = Endpoint("countries")
+	EndpointLookups      = Endpoint("lookups") // BIC lookups
+	EndpointBalances     = Endpoint("balances")
+	EndpointCreditParty  = Endpoint("credit_party") // credit party information and verification
+	EndpointQuotations   = Endpoint("quotations")
+	EndpointTransactions = Endpoint("transactions")
+	EndpointAttachments  = Endpoint("attachments")
+	// EndpointOther is any url unknown to the client, e.g. requested with NewRequest.
+	EndpointOther = Endpoint("other")
+)
+
+// endpointOf returns the endpoint at url, relative to the base url.
+func endpointOf(url string) Endpoint {
+	if url == "ping" {
+		return EndpointPing
+	}
+
+	path := strings.TrimPrefix(url, "v2/money-transfer/")
+	switch {
+	case strings.HasPrefix(path, "quotations/") && strings.HasSuffix(path, "/transactions"):
+		// creates the transaction of a quotation
+		return EndpointTransactions
+	case strings.HasPrefix(path, "quotations"):
+		return EndpointQuotations
+	case strings.HasPrefix(path, "transactions/") && strings.HasSuffix(path, "/attachments"):
+		return EndpointAttachments
+	case strings.HasPrefix(path, "transactions"):
+		return EndpointTransactions
+	case strings.HasPrefix(path, "payers/") && strings.Contains(path, "/credit-party-"):
+		return EndpointCreditParty
+	case strings.HasPrefix(path, "payers/") && strings.HasSuffix(path, "/rates"):
+		return EndpointRates
+	case strings.HasPrefix(path, "payers"):
+		return EndpointPayers
+	case strings.HasPrefix(path, "services"):
+		return EndpointServices
+	case strings.HasPrefix(path, "countries"):
+		return EndpointCountries
+	case strings.HasPrefix(path, "lookups"):
+		return EndpointLookups
+	case strings.HasPrefix(path, "balances"):
+		return EndpointBalances
+	}
+	return EndpointOther
+}
+
+// MetricsHook records the activity of a client, see WithMetrics. Its methods are called concurrently
+// from the goroutines using the client and must return quickly. metrics.Collector implements it.
+type MetricsHook interface {
+	// ObserveRequest is called once per attempt of every request.
+	ObserveRequest(m RequestMetrics)
+	// ObservePayout is called for every transaction confirmed.
+	ObservePayout(m PayoutMetrics)
+}
+
+// RequestMetrics describes an attempt of a request.
+type RequestMetrics struct {
+	Endpoint Endpoint
+	Group    EndpointGroup
+	Method   string
+	// Attempt is the number of the attempt, starting at 1. The attempts above 1 are retries.
+	Attempt int
+	// StatusCode is the status of the response, zero when none was received.
+	StatusCode int
+	// Duration is the time taken by the API to answer, the rate limits excluded.
+	Duration time.Duration
+	// ErrorCodes are the Thunes error codes of a failed request.
+	ErrorCodes []string
+	// Err is the error met when no response was received.
+	Err error
+}
+
+// PayoutMetrics describes a confirmed transaction.
+type PayoutMetrics struct {
+	TransactionType pkg.TransactionType
+	// Source is the amount sent, Destination the amount paid out. Either is zero if Thunes did not return it.
+	Source      pkg.Money
+	Destination pkg.Money
+}
+
+// WithMetrics reports the requests and the payouts of the client to hook.
+func WithMetrics(hook MetricsHook) Option {
+	return func(tc *ThunesClient) {
+		tc.metrics = hook
+	}
+}
+
+// measure reports the requests sent by next to the metrics hook.
+func (tc *ThunesClient) measure(next Handler) Handler {
+	return HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		m := RequestMetrics{Endpoint: EndpointOther, Group: GroupOther, Method: req.Method}
+		if info, ok := RequestInfoFrom(req); ok {
+			m.Endpoint, m.Group, m.Attempt = info.Endpoint, info.Group, info.Attempt
+		}
+
+		start := time.Now()
+		resp, err := next.Do(req)
+		m.Duration = time.Since(start)
+		if err != nil {
+			m.Err = err
+			tc.metrics.ObserveRequest(m)
+			return nil, err
+		}
+
+		m.StatusCode = resp.StatusCode
+		if resp.StatusCode >= 300 {
+			data, err := peekBody(resp, maxErrorBody)
+			if err == nil {
+				m.ErrorCodes = errorCodes(data)
+			}
+		}
+		tc.metrics.ObserveRequest(m)
+		return resp, nil
+	})
+}
+
+// errorCodes returns the codes of a Thunes error document, nil if data is not one.
+func errorCodes(data []byte) []string {
+	var errs pkg.Errors
+	if err := json.Unmarshal(data, &errs); err != nil || len(errs.Errors) == 0 {
+		return nil
+	}
+
+	codes := make([]string, len(errs.Errors))
+	for i, e := range errs.Errors {
+		codes[i] = e.Code
+	}
+	return codes
+}
+
+// observePayout reports a confirmed transaction to the metrics hook.
+func (tc *ThunesClient) observePayout(transaction *pkg.Transaction) {
+	if tc.metrics == nil {
+		return
+	}
+
+	var m PayoutMetrics
+	if transaction.TransactionType != nil {
+		m.TransactionType = *transaction.TransactionType
+	}
+	if transaction.Source != nil {
+		m.Source = transaction.Source.Money()
+	}
+	if transaction.Destination != nil {
+		m.Destination = *transaction.Destination
+	}
+	tc.metrics.ObservePayout(m)
+}
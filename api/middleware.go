@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Handler sends a request to the Thunes API and returns its response.
+type Handler interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f HandlerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the handler sending the requests, e.g. to log, measure, trace, mutate or fail them:
+//
+//	tracing := func(next api.Handler) api.Handler {
+//		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+//			req.Header.Set("X-Request-Id", newRequestID())
+//			return next.Do(req)
+//		})
+//	}
+//	tc := api.NewThunesClient(key, secret, api.WithMiddleware(tracing))
+//
+// Every attempt of every request goes through the middlewares, with its authentication and User-Agent
+// headers already set. A middleware reading the request body must restore it, req.GetBody returns a copy.
+// The rate limits and the in-flight cap are applied after the middlewares, right before the request is sent.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middlewares to the client, the first one sees the requests first.
+// The middlewares of several WithMiddleware options are chained in the order of the options.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(tc *ThunesClient) {
+		tc.middlewares = append(tc.middlewares, middlewares...)
+	}
+}
+
+// RequestInfo describes the call a request is an attempt of.
+type RequestInfo struct {
+	// Endpoint is the endpoint of the request, Group its endpoint group.
+	Endpoint Endpoint
+	Group    EndpointGroup
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+}
+
+type requestInfoKey struct{}
+
+// RequestInfoFrom returns the description of a request sent by a ThunesClient, for middlewares.
+// It returns false when the request was not sent by a ThunesClient.
+func RequestInfoFrom(req *http.Request) (RequestInfo, bool) {
+	info, ok := req.Context().Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
+
+func withRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// pipeline chains the handlers every request goes through: authentication, the middlewares of the client,
+// the limiter, the logger and the metrics hook, then the http client.
+func (tc *ThunesClient) pipeline() Handler {
+	var h Handler = HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		return tc.httpClient.Do(req)
+	})
+	if tc.metrics != nil {
+		h = tc.measure(h)
+	}
+	if tc.logger != nil {
+		h = tc.logRequests(h)
+	}
+	h = tc.limit(h)
+	for i := len(tc.middlewares) - 1; i >= 0; i-- {
+		h = tc.middlewares[i](h)
+	}
+	return tc.authenticate(h)
+}
+
+// authenticate sets the authentication and the User-Agent headers of the requests.
+func (tc *ThunesClient) authenticate(next Handler) Handler {
+	return HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		if err := tc.authorize(req); err != nil {
+			return nil, err
+		}
+		return next.Do(req)
+	})
+}
+
+// limit waits for the rate limit of the endpoint group of the requests and for a free slot,
+// and slows the group down on 429 responses. The slot is freed when the response body is closed.
+func (tc *ThunesClient) limit(next Handler) Handler {
+	return HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		group := GroupOther
+		if info, ok := RequestInfoFrom(req); ok {
+			group = info.Group
+		}
+
+		release, err := tc.limiter.acquire(req.Context(), group)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.Do(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			tc.limiter.throttled(group, retryAfter(resp.Header))
+		}
+		if resp.Body == nil {
+			resp.Body = http.NoBody
+		}
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+// releasingBody calls release once the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,139 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"thunes-client/api"
+	"thunes-client/thunestest"
+)
+
+// trace records the calls of the middlewares built by its step method.
+type trace struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (tr *trace) add(step string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.steps = append(tr.steps, step)
+}
+
+func (tr *trace) String() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return strings.Join(tr.steps, " ")
+}
+
+// step returns a middleware recording name before and after the rest of the pipeline.
+func (tr *trace) step(name string) api.Middleware {
+	return func(next api.Handler) api.Handler {
+		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+			tr.add(name + ">")
+			resp, err := next.Do(req)
+			tr.add("<" + name)
+			return resp, err
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv, _ := newServer(t)
+	tr := &trace{}
+	tc := srv.Client(
+		api.WithRetryPolicy(api.NoRetry),
+		api.WithMiddleware(tr.step("a"), tr.step("b")),
+		api.WithMiddleware(tr.step("c")),
+	)
+
+	if _, err := tc.AllBalances(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tr.String(), "a> b> c> <c <b <a"; got != want {
+		t.Errorf("steps = %s, want %s", got, want)
+	}
+}
+
+func TestMiddlewareSeesAuthenticatedAttempts(t *testing.T) {
+	srv, _ := newServer(t)
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/balances", StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	tr := &trace{}
+	inspect := func(next api.Handler) api.Handler {
+		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+			info, ok := api.RequestInfoFrom(req)
+			key, secret, authenticated := req.BasicAuth()
+			if !ok || !authenticated || key != thunestest.APIKey || secret != thunestest.APISecret || req.UserAgent() != "tests" {
+				t.Errorf("request %s not authenticated, or without its info", req.URL)
+			}
+			tr.add(fmt.Sprintf("%s/%s#%d", info.Group, info.Endpoint, info.Attempt))
+			return next.Do(req)
+		})
+	}
+	tc := srv.Client(api.WithRetryPolicy(fastRetries), api.WithUserAgent("tests"), api.WithMiddleware(inspect))
+
+	if _, err := tc.AllBalances(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tr.String(), "other/balances#1 other/balances#2 other/balances#3"; got != want {
+		t.Errorf("attempts = %s, want %s", got, want)
+	}
+}
+
+func TestMiddlewareShortCircuits(t *testing.T) {
+	srv, _ := newServer(t)
+	errBlocked := errors.New("blocked by policy")
+	tr := &trace{}
+	block := func(next api.Handler) api.Handler {
+		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errBlocked
+		})
+	}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithMiddleware(tr.step("a"), block, tr.step("b")))
+
+	if _, err := tc.AllBalances(context.Background()); !errors.Is(err, errBlocked) {
+		t.Errorf("AllBalances() error = %v, want the error of the middleware", err)
+	}
+	if got := tr.String(); got != "a> <a" {
+		t.Errorf("steps = %s, want the middlewares after the failing one skipped", got)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests reached the server", n)
+	}
+}
+
+func TestMiddlewareReadsBody(t *testing.T) {
+	srv, _ := newServer(t)
+	var bodies []string
+	record := func(next api.Handler) api.Handler {
+		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				data, err := ioutil.ReadAll(body)
+				if err != nil {
+					return nil, err
+				}
+				bodies = append(bodies, string(data))
+			}
+			return next.Do(req)
+		})
+	}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithMiddleware(record))
+
+	if _, err := tc.SendMoney(context.Background(), payoutRequest("payout-1")); err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if len(bodies) == 0 || !strings.Contains(bodies[0], `"external_id":"payout-1"`) {
+		t.Errorf("bodies = %q, want the quotation first", bodies)
+	}
+}
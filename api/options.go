@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Environment identifies a Thunes API environment by its base url.
+type Environment string
+
+const (
+	// Preproduction is the Thunes sandbox environment, no real money is moved.
+	Preproduction = Environment("https://api-mt.pre.thunes.com/")
+	// Production is the live Thunes environment.
+	Production = Environment("https://api-mt.thunes.com/")
+)
+
+const (
+	// DefaultTimeout is the overall timeout applied to every request when no other timeout is configured.
+	DefaultTimeout = 30 * time.Second
+	// DefaultUserAgent is the User-Agent header sent when WithUserAgent is not used.
+	DefaultUserAgent = "thunes-client-go"
+)
+
+// Option configures a ThunesClient.
+type Option func(*ThunesClient)
+
+// WithEnvironment sends all requests to the given Thunes environment.
+func WithEnvironment(env Environment) Option {
+	return func(tc *ThunesClient) {
+		tc.baseUrl = string(env)
+	}
+}
+
+// WithBaseURL sends all requests to a custom base url, e.g. a proxy or a test server.
+func WithBaseURL(baseUrl string) Option {
+	return func(tc *ThunesClient) {
+		if !strings.HasSuffix(baseUrl, "/") {
+			baseUrl += "/"
+		}
+		tc.baseUrl = baseUrl
+	}
+}
+
+// WithHTTPClient uses the given http.Client instead of the default one, a nil client keeps the default one.
+// The client keeps its own timeout unless WithTimeout is used. It is copied, together with its transport
+// when WithProxy or WithTLSConfig is used, so that the caller's client is never mutated.
+func WithHTTPClient(client *http.Client) Option {
+	return func(tc *ThunesClient) {
+		if client == nil {
+			return
+		}
+		c := *client
+		tc.httpClient = &c
+	}
+}
+
+// WithTimeout sets the overall timeout of a single request, zero disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(tc *ThunesClient) {
+		tc.timeout = &timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(tc *ThunesClient) {
+		tc.userAgent = userAgent
+	}
+}
+
+// WithProxy sends every request through the given proxy.
+// It only has an effect when the transport of the http client is an *http.Transport.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(tc *ThunesClient) {
+		tc.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithTLSConfig uses the given TLS configuration, e.g. to present client certificates or trust a private CA.
+// It only has an effect when the transport of the http client is an *http.Transport, a nil config keeps its own.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(tc *ThunesClient) {
+		tc.tlsConfig = config
+	}
+}
+
+// configureHTTPClient applies the settings of WithTimeout, WithProxy and WithTLSConfig to the http client
+// chosen by the options, whatever their order. The transport is modified on a private copy.
+func (tc *ThunesClient) configureHTTPClient() {
+	if tc.timeout != nil {
+		tc.httpClient.Timeout = *tc.timeout
+	}
+	if tc.proxy == nil && tc.tlsConfig == nil {
+		return
+	}
+
+	var t *http.Transport
+	switch rt := tc.httpClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		// a custom http.RoundTripper is left as is
+		return
+	}
+
+	if tc.proxy != nil {
+		t.Proxy = tc.proxy
+	}
+	if tc.tlsConfig != nil {
+		t.TLSClientConfig = tc.tlsConfig
+	}
+	tc.httpClient.Transport = t
+}
@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/thunestest"
+)
+
+func TestTimeoutAppliedWhateverTheOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts func(client *http.Client) []api.Option
+	}{
+		{name: "timeout first", opts: func(client *http.Client) []api.Option {
+			return []api.Option{api.WithTimeout(20 * time.Millisecond), api.WithHTTPClient(client)}
+		}},
+		{name: "timeout last", opts: func(client *http.Client) []api.Option {
+			return []api.Option{api.WithHTTPClient(client), api.WithTimeout(20 * time.Millisecond)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := thunestest.NewServer()
+			defer srv.Close()
+			srv.InjectFault(thunestest.Fault{Path: "/v2/money-transfer/balances", Delay: time.Second})
+
+			client := &http.Client{}
+			tc := srv.Client(append(tt.opts(client), api.WithRetryPolicy(api.NoRetry))...)
+
+			start := time.Now()
+			_, err := tc.AllBalances(context.Background())
+			var netErr interface{ Timeout() bool }
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Errorf("AllBalances() error = %v, want a timeout", err)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("AllBalances() took %v, want the 20ms timeout", elapsed)
+			}
+			if client.Timeout != 0 {
+				t.Errorf("the caller's client timeout was set to %v", client.Timeout)
+			}
+		})
+	}
+}
+
+func TestHTTPClientKeepsItsTimeout(t *testing.T) {
+	srv := thunestest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(thunestest.Fault{Path: "/v2/money-transfer/balances", Delay: time.Second})
+
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	if _, err := tc.AllBalances(context.Background()); err == nil {
+		t.Error("AllBalances() succeeded past the timeout of the client")
+	}
+}
@@ -0,0 +1,445 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"thunes-client/pkg"
+)
+
+// MaxPerPage is the largest page size accepted by the Thunes list endpoints.
+const MaxPerPage = 100
+
+// PageOption configures how an iterator walks the pages of a list endpoint.
+type PageOption func(*pageIterator)
+
+// PerPage sets the number of items requested per page, it defaults to MaxPerPage.
+func PerPage(n int) PageOption {
+	return func(it *pageIterator) {
+		if n > 0 && n <= MaxPerPage {
+			it.perPage = n
+		}
+	}
+}
+
+// Prefetch fetches up to n pages ahead of the one being consumed, concurrently.
+// Pages are only prefetched once the X-Total-Count header tells how many there are,
+// so nothing is requested past the last page.
+func Prefetch(n int) PageOption {
+	return func(it *pageIterator) {
+		it.prefetch = n
+	}
+}
+
+// MaxItems stops the iteration after n items.
+func MaxItems(n int) PageOption {
+	return func(it *pageIterator) {
+		it.maxItems = n
+	}
+}
+
+// pageIterator walks the pages of a list endpoint and hands out their items one by one.
+// Each item is kept as raw JSON, the typed iterators decode it.
+//
+// An iterator holds a context, and with Prefetch requests running in the background, until Next returns false
+// or it is closed: the typed iterators document Close as mandatory when the loop is left early.
+type pageIterator struct {
+	tc       *ThunesClient
+	ctx      context.Context
+	cancel   context.CancelFunc
+	url      string
+	query    map[string]string
+	perPage  int
+	prefetch int
+	maxItems int
+
+	items    []json.RawMessage // items of the current page not handed out yet
+	page     int               // next page to consume
+	total    int               // total number of items, -1 until known
+	count    int               // number of items handed out
+	inflight map[int]chan pageResult
+	lastPage bool
+	done     bool
+	err      error
+}
+
+type pageResult struct {
+	items []json.RawMessage
+	total int
+	err   error
+}
+
+func (tc *ThunesClient) newPageIterator(ctx context.Context, url string, query map[string]string, opts []PageOption) *pageIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &pageIterator{
+		tc:       tc,
+		ctx:      ctx,
+		cancel:   cancel,
+		url:      url,
+		query:    query,
+		perPage:  MaxPerPage,
+		page:     1,
+		total:    -1,
+		inflight: make(map[int]chan pageResult),
+	}
+
+	for _, opt := range opts {
+		opt(it)
+	}
+
+	return it
+}
+
+// next decodes the next item into v, it returns false once the items are exhausted or an error occurred.
+func (it *pageIterator) next(v interface{}) bool {
+	if it.done {
+		return false
+	}
+
+	if it.maxItems > 0 && it.count >= it.maxItems {
+		it.close()
+		return false
+	}
+
+	for len(it.items) == 0 {
+		if it.lastPage || !it.nextPage() {
+			it.close()
+			return false
+		}
+	}
+
+	item := it.items[0]
+	it.items = it.items[1:]
+	if err := json.Unmarshal(item, v); err != nil {
+		it.err = err
+		it.close()
+		return false
+	}
+
+	it.count++
+	return true
+}
+
+// nextPage loads the items of the next page, it returns false if the page could not be fetched.
+func (it *pageIterator) nextPage() bool {
+	ch, ok := it.inflight[it.page]
+	if ok {
+		delete(it.inflight, it.page)
+	} else {
+		ch = it.fetch(it.page)
+	}
+
+	res := <-ch
+	if res.err != nil {
+		it.err = res.err
+		return false
+	}
+
+	if res.total >= 0 {
+		it.total = res.total
+	}
+
+	// a short page, or reaching the total count, marks the last page
+	if len(res.items) < it.perPage || (it.total >= 0 && it.page*it.perPage >= it.total) {
+		it.lastPage = true
+	}
+
+	it.items = res.items
+	it.page++
+
+	if !it.lastPage {
+		it.prefetchPages()
+	}
+
+	return true
+}
+
+// prefetchPages keeps up to it.prefetch of the following pages in flight.
+func (it *pageIterator) prefetchPages() {
+	if it.prefetch <= 0 || it.total < 0 {
+		return
+	}
+
+	lastPage := (it.total + it.perPage - 1) / it.perPage
+	for page := it.page; page < it.page+it.prefetch && page <= lastPage; page++ {
+		if _, ok := it.inflight[page]; !ok {
+			it.inflight[page] = it.fetch(page)
+		}
+	}
+}
+
+// fetch requests the given page in the background.
+func (it *pageIterator) fetch(page int) chan pageResult {
+	ch := make(chan pageResult, 1)
+	go func() {
+		ch <- it.get(page)
+	}()
+	return ch
+}
+
+func (it *pageIterator) get(page int) pageResult {
+	// construct query params, the filters are shared by every page
+	queryParams := make(map[string]string, len(it.query)+2)
+	for key, value := range it.query {
+		queryParams[key] = value
+	}
+	queryParams["page"] = fmt.Sprint(page)
+	queryParams["per_page"] = fmt.Sprint(it.perPage)
+
+	// construct the request
+	resp, err := it.tc.send(it.ctx, &request{
+		method:         http.MethodGet,
+		url:            it.url,
+		expectedStatus: http.StatusOK,
+		queryParams:    queryParams,
+		replayable:     true,
+	})
+	if err != nil {
+		return pageResult{err: err}
+	}
+
+	// handle the success case response
+	res := pageResult{total: -1}
+	if err = json.Unmarshal(resp.body, &res.items); err != nil {
+		return pageResult{err: err}
+	}
+	if total, err := strconv.Atoi(resp.header.Get("X-Total-Count")); err == nil {
+		res.total = total
+	}
+
+	return res
+}
+
+// close stops the iteration and aborts any prefetched page still in flight.
+func (it *pageIterator) close() {
+	it.done = true
+	it.items = nil
+	it.cancel()
+}
+
+// ServicesIterator walks every service matching a ListServices query.
+type ServicesIterator struct {
+	pages   *pageIterator
+	service pkg.Service
+}
+
+// IterateServices returns an iterator over every service available to the caller, optionally filtered by country.
+func (tc *ThunesClient) IterateServices(ctx context.Context, countryISOCode *string, opts ...PageOption) *ServicesIterator {
+	query := make(map[string]string)
+	if countryISOCode != nil {
+		query["country_iso_code"] = *countryISOCode
+	}
+
+	return &ServicesIterator{pages: tc.newPageIterator(ctx, "v2/money-transfer/services", query, opts)}
+}
+
+// Next advances to the next service, it returns false when there are no more services or an error occurred.
+func (it *ServicesIterator) Next() bool {
+	it.service = pkg.Service{}
+	return it.pages.next(&it.service)
+}
+
+// Service returns the current service.
+func (it *ServicesIterator) Service() pkg.Service { return it.service }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ServicesIterator) Err() error { return it.pages.err }
+
+// Close stops the iteration and aborts the pages prefetched in the background.
+// It must be called when the iteration is left before Next returns false, calling it again is harmless.
+func (it *ServicesIterator) Close() { it.pages.close() }
+
+// AllServices returns every service available to the caller, optionally filtered by country.
+func (tc *ThunesClient) AllServices(ctx context.Context, countryISOCode *string, opts ...PageOption) ([]pkg.Service, error) {
+	var services []pkg.Service
+	it := tc.IterateServices(ctx, countryISOCode, opts...)
+	defer it.Close()
+	for it.Next() {
+		services = append(services, it.Service())
+	}
+
+	return services, it.Err()
+}
+
+// PayersIterator walks every payer matching a ListPayers query.
+type PayersIterator struct {
+	pages *pageIterator
+	payer pkg.Payer
+}
+
+// IteratePayers returns an iterator over every payer available to the caller.
+// The filters are optional, nil references can be passed.
+//
+// Like every iterator, it must be closed when the loop may be left before Next returns false,
+// the simplest being to defer Close:
+//
+//	it := tc.IteratePayers(ctx, nil, &country, nil, api.Prefetch(2))
+//	defer it.Close()
+//	for it.Next() {
+//		if it.Payer().Name == name {
+//			break
+//		}
+//	}
+func (tc *ThunesClient) IteratePayers(ctx context.Context, serviceID *int, countryISOCode, currency *string, opts ...PageOption) *PayersIterator {
+	query := make(map[string]string)
+	if serviceID != nil {
+		query["service_id"] = fmt.Sprint(*serviceID)
+	}
+	if countryISOCode != nil {
+		query["country_iso_code"] = *countryISOCode
+	}
+	if currency != nil {
+		query["currency"] = *currency
+	}
+
+	return &PayersIterator{pages: tc.newPageIterator(ctx, "v2/money-transfer/payers", query, opts)}
+}
+
+// Next advances to the next payer, it returns false when there are no more payers or an error occurred.
+func (it *PayersIterator) Next() bool {
+	it.payer = pkg.Payer{}
+	return it.pages.next(&it.payer)
+}
+
+// Payer returns the current payer.
+func (it *PayersIterator) Payer() pkg.Payer { return it.payer }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *PayersIterator) Err() error { return it.pages.err }
+
+// Close stops the iteration and aborts the pages prefetched in the background.
+// It must be called when the iteration is left before Next returns false, calling it again is harmless.
+func (it *PayersIterator) Close() { it.pages.close() }
+
+// AllPayers returns every payer available to the caller.
+// The filters are optional, nil references can be passed.
+func (tc *ThunesClient) AllPayers(ctx context.Context, serviceID *int, countryISOCode, currency *string, opts ...PageOption) ([]pkg.Payer, error) {
+	var payers []pkg.Payer
+	it := tc.IteratePayers(ctx, serviceID, countryISOCode, currency, opts...)
+	defer it.Close()
+	for it.Next() {
+		payers = append(payers, it.Payer())
+	}
+
+	return payers, it.Err()
+}
+
+// CountriesIterator walks every country returned by ListCountriesAvailable.
+type CountriesIterator struct {
+	pages   *pageIterator
+	country pkg.Country
+}
+
+// IterateCountries returns an iterator over the countries of every money transfer service available to the caller.
+func (tc *ThunesClient) IterateCountries(ctx context.Context, opts ...PageOption) *CountriesIterator {
+	return &CountriesIterator{pages: tc.newPageIterator(ctx, "v2/money-transfer/countries", nil, opts)}
+}
+
+// Next advances to the next country, it returns false when there are no more countries or an error occurred.
+func (it *CountriesIterator) Next() bool {
+	it.country = pkg.Country{}
+	return it.pages.next(&it.country)
+}
+
+// Country returns the current country.
+func (it *CountriesIterator) Country() pkg.Country { return it.country }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *CountriesIterator) Err() error { return it.pages.err }
+
+// Close stops the iteration and aborts the pages prefetched in the background.
+// It must be called when the iteration is left before Next returns false, calling it again is harmless.
+func (it *CountriesIterator) Close() { it.pages.close() }
+
+// AllCountries returns the countries of every money transfer service available to the caller.
+func (tc *ThunesClient) AllCountries(ctx context.Context, opts ...PageOption) ([]pkg.Country, error) {
+	var countries []pkg.Country
+	it := tc.IterateCountries(ctx, opts...)
+	defer it.Close()
+	for it.Next() {
+		countries = append(countries, it.Country())
+	}
+
+	return countries, it.Err()
+}
+
+// LookupsIterator walks every payer identifier returned by BICCodeLookup.
+type LookupsIterator struct {
+	pages  *pageIterator
+	lookup pkg.Lookup
+}
+
+// IterateBICCodeLookup returns an iterator over the payers' identifiers for a given SWIFT BIC code.
+func (tc *ThunesClient) IterateBICCodeLookup(ctx context.Context, swiftBICCode string, opts ...PageOption) *LookupsIterator {
+	return &LookupsIterator{pages: tc.newPageIterator(ctx, fmt.Sprintf("v2/money-transfer/lookups/BIC/%s", swiftBICCode), nil, opts)}
+}
+
+// Next advances to the next lookup, it returns false when there are no more lookups or an error occurred.
+func (it *LookupsIterator) Next() bool {
+	it.lookup = pkg.Lookup{}
+	return it.pages.next(&it.lookup)
+}
+
+// Lookup returns the current lookup.
+func (it *LookupsIterator) Lookup() pkg.Lookup { return it.lookup }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *LookupsIterator) Err() error { return it.pages.err }
+
+// Close stops the iteration and aborts the pages prefetched in the background.
+// It must be called when the iteration is left before Next returns false, calling it again is harmless.
+func (it *LookupsIterator) Close() { it.pages.close() }
+
+// AllBICCodeLookups returns every payers' identifier for a given SWIFT BIC code.
+func (tc *ThunesClient) AllBICCodeLookups(ctx context.Context, swiftBICCode string, opts ...PageOption) ([]pkg.Lookup, error) {
+	var lookups []pkg.Lookup
+	it := tc.IterateBICCodeLookup(ctx, swiftBICCode, opts...)
+	defer it.Close()
+	for it.Next() {
+		lookups = append(lookups, it.Lookup())
+	}
+
+	return lookups, it.Err()
+}
+
+// BalancesIterator walks every account balance returned by GetBalances.
+type BalancesIterator struct {
+	pages   *pageIterator
+	balance pkg.Balance
+}
+
+// IterateBalances returns an iterator over the account balances of every currency.
+func (tc *ThunesClient) IterateBalances(ctx context.Context, opts ...PageOption) *BalancesIterator {
+	return &BalancesIterator{pages: tc.newPageIterator(ctx, "v2/money-transfer/balances", nil, opts)}
+}
+
+// Next advances to the next balance, it returns false when there are no more balances or an error occurred.
+func (it *BalancesIterator) Next() bool {
+	it.balance = pkg.Balance{}
+	return it.pages.next(&it.balance)
+}
+
+// Balance returns the current balance.
+func (it *BalancesIterator) Balance() pkg.Balance { return it.balance }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *BalancesIterator) Err() error { return it.pages.err }
+
+// Close stops the iteration and aborts the pages prefetched in the background.
+// It must be called when the iteration is left before Next returns false, calling it again is harmless.
+func (it *BalancesIterator) Close() { it.pages.close() }
+
+// AllBalances returns the account balances of every currency.
+func (tc *ThunesClient) AllBalances(ctx context.Context, opts ...PageOption) ([]pkg.Balance, error) {
+	var balances []pkg.Balance
+	it := tc.IterateBalances(ctx, opts...)
+	defer it.Close()
+	for it.Next() {
+		balances = append(balances, it.Balance())
+	}
+
+	return balances, it.Err()
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+// newBalancesServer serves five balances, so that they span several pages.
+func newBalancesServer(t *testing.T) *thunestest.Server {
+	t.Helper()
+	fixtures := thunestest.DefaultFixtures()
+	fixtures.Balances = nil
+	for i, currency := range []string{"USD", "EUR", "GBP", "SGD", "JPY"} {
+		amount := pkg.DecimalFromInt(1000)
+		fixtures.Balances = append(fixtures.Balances, pkg.Balance{ID: i + 1, Currency: currency, Balance: amount, Available: amount})
+	}
+	srv := thunestest.NewServer(thunestest.WithFixtures(fixtures))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func currencies(balances []pkg.Balance) string {
+	var codes []string
+	for _, b := range balances {
+		codes = append(codes, b.Currency)
+	}
+	return strings.Join(codes, ",")
+}
+
+func TestIteratePages(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []api.PageOption
+		want     string
+		requests int
+	}{
+		{name: "one page", want: "USD,EUR,GBP,SGD,JPY", requests: 1},
+		{name: "several pages", opts: []api.PageOption{api.PerPage(2)}, want: "USD,EUR,GBP,SGD,JPY", requests: 3},
+		{name: "prefetch", opts: []api.PageOption{api.PerPage(2), api.Prefetch(2)}, want: "USD,EUR,GBP,SGD,JPY", requests: 3},
+		{name: "prefetch past the end", opts: []api.PageOption{api.PerPage(1), api.Prefetch(10)}, want: "USD,EUR,GBP,SGD,JPY", requests: 5},
+		{name: "max items", opts: []api.PageOption{api.PerPage(2), api.MaxItems(3)}, want: "USD,EUR,GBP", requests: 2},
+		{name: "max items on a page boundary", opts: []api.PageOption{api.PerPage(2), api.MaxItems(2)}, want: "USD,EUR", requests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newBalancesServer(t)
+			tc := srv.Client(api.WithRetryPolicy(api.NoRetry))
+
+			balances, err := tc.AllBalances(context.Background(), tt.opts...)
+			if err != nil {
+				t.Fatalf("AllBalances() error = %v", err)
+			}
+			if got := currencies(balances); got != tt.want {
+				t.Errorf("balances = %s, want %s", got, tt.want)
+			}
+			if n := countRequests(srv, http.MethodGet, "/v2/money-transfer/balances"); n != tt.requests {
+				t.Errorf("%d requests, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestIterateError(t *testing.T) {
+	srv := newBalancesServer(t)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry))
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/balances", StatusCode: http.StatusInternalServerError})
+
+	it := tc.IterateBalances(context.Background(), api.PerPage(2))
+	defer it.Close()
+	if it.Next() {
+		t.Fatal("Next() succeeded on a failed page")
+	}
+	if it.Err() == nil {
+		t.Error("Err() = nil, want the error of the page")
+	}
+}
+
+func TestIteratorCloseCancelsPrefetch(t *testing.T) {
+	srv := newBalancesServer(t)
+
+	// the pages after the first one hang until their request is cancelled
+	cancelled := make(chan struct{}, 4)
+	hang := func(next api.Handler) api.Handler {
+		return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("page") == "1" {
+				return next.Do(req)
+			}
+			<-req.Context().Done()
+			cancelled <- struct{}{}
+			return nil, req.Context().Err()
+		})
+	}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithMiddleware(hang))
+
+	it := tc.IterateBalances(context.Background(), api.PerPage(2), api.Prefetch(1))
+	if !it.Next() || it.Balance().Currency != "USD" {
+		t.Fatalf("Next() = %s, %v, want USD", it.Balance().Currency, it.Err())
+	}
+	it.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the prefetched page was not cancelled by Close")
+	}
+	if it.Next() {
+		t.Error("Next() succeeded after Close")
+	}
+	if it.Err() != nil {
+		t.Errorf("Err() = %v after Close, want nil", it.Err())
+	}
+	it.Close()
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// Quotation modes, selecting which side of a payout has a fixed amount.
+const (
+	SourceAmount      = pkg.SourceAmount
+	DestinationAmount = pkg.DestinationAmount
+)
+
+// ErrTransactionFailed is returned by SendMoney when the transaction of the payout was rejected, cancelled,
+// reversed or declined, e.g. by an earlier call which failed.
+var ErrTransactionFailed = errors.New("thunes: transaction failed")
+
+// ErrUnknownStatus is returned by SendMoney when the status of the transaction of the payout cannot be told.
+var ErrUnknownStatus = errors.New("thunes: unknown transaction status")
+
+// ErrRateDrift is returned by SendMoney when the rate of a re-created quotation drifted beyond RequotePolicy.MaxRateDrift.
+var ErrRateDrift = errors.New("thunes: quotation rate drifted")
+
+// maxRequotes bounds the number of quotations re-created for a payout.
+const maxRequotes = 10
+
+// compensationTimeout bounds the cancellation of a transaction after a failed payout,
+// which runs even if the payout's context is done.
+const compensationTimeout = 30 * time.Second
+
+// Payout steps reported by PayoutError.
+const (
+	StepLookup      = "lookup"
+	StepValidation  = "validation"
+	StepQuotation   = "quotation"
+	StepBalance     = "balance"
+	StepTransaction = "transaction"
+	StepAttachment  = "attachment"
+	StepConfirm     = "confirm"
+)
+
+// PayoutRequest describes a payout performed by SendMoney.
+type PayoutRequest struct {
+	// ExternalID identifies the payout, it is used as the external id of both the quotation and the transaction.
+	// Sending the same payout twice is safe: the second call resumes or returns the first one.
+	// The quotations re-created by Requote get it suffixed with -r1, -r2 and so on.
+	ExternalID string
+
+	PayerID              int
+	TransactionType      pkg.TransactionType
+	SourceCurrency       string
+	SourceCountryISOCode string
+	DestinationCurrency  string
+
+	// Mode is SourceAmount or DestinationAmount, it selects which currency Amount is expressed in.
+	Mode   pkg.QuotationMode
+	Amount pkg.Decimal
+
+	// Transaction holds the sender, beneficiary and credit party details of the transaction, its ExternalID is ignored.
+	Transaction pkg.CreateTransactionRequest
+
+	// Attachments are added to the transaction before it is confirmed.
+	Attachments []PayoutAttachment
+
+	// SkipBalanceCheck skips checking that the balance covers the sent amount before the transaction is created.
+	SkipBalanceCheck bool
+	// SkipValidation skips checking Transaction against the requirements of the payer, see pkg.Validate.
+	SkipValidation bool
+
+	// Requote re-creates the quotation when it has expired or is about to, instead of failing the payout.
+	Requote *RequotePolicy
+}
+
+// RequotePolicy configures how SendMoney re-creates the quotation of a payout before creating its transaction.
+type RequotePolicy struct {
+	// MinTimeLeft is the time a quotation must have left for the transaction to be created from it,
+	// a quotation expiring sooner is re-created.
+	MinTimeLeft time.Duration
+	// MaxRateDrift is the largest relative change of the wholesale FX rate accepted between the first quotation
+	// and a re-created one, e.g. 0.005 for 0.5%. The payout fails with ErrRateDrift beyond it,
+	// the zero value only accepts the same rate.
+	MaxRateDrift pkg.Decimal
+}
+
+// PayoutAttachment is a document attached to the transaction of a payout.
+type PayoutAttachment struct {
+	Name string
+	Type pkg.TransactionAttachmentType
+	File *os.File
+}
+
+// PayoutResult holds every object obtained while performing a payout.
+// Fields are nil for the steps which were not reached.
+type PayoutResult struct {
+	Quotation *pkg.Quotation
+	// ExpiredQuotations are the quotations replaced by Requote, oldest first.
+	ExpiredQuotations []*pkg.Quotation
+
+	Balance     *pkg.Balance
+	Transaction *pkg.Transaction
+	Attachments []pkg.TransactionAttachment
+
+	// Confirmed is the transaction once confirmed.
+	Confirmed *pkg.Transaction
+	// Cancelled is the transaction once cancelled after a failure following its creation.
+	Cancelled *pkg.Transaction
+
+	// Resumed reports that the payout had already been started by an earlier call.
+	Resumed bool
+}
+
+// PayoutError reports the step at which a payout failed.
+type PayoutError struct {
+	Step string
+	Err  error
+}
+
+func (e *PayoutError) Error() string {
+	return fmt.Sprintf("payout failed at %s step: %v", e.Step, e.Err)
+}
+
+func (e *PayoutError) Unwrap() error {
+	return e.Err
+}
+
+// SendMoney performs a payout end to end: it validates the transaction against the payer's requirements,
+// creates the quotation, checks the balance covers it, creates the transaction, adds the attachments
+// and confirms the transaction.
+//
+// If the transaction was created but a later step fails, the transaction is cancelled.
+// When the transaction of the external id was rejected, cancelled, reversed or declined, e.g. by an earlier call,
+// the error wraps ErrTransactionFailed. The result holds every object obtained so far, even when an error is returned.
+func (tc *ThunesClient) SendMoney(ctx context.Context, req *PayoutRequest) (*PayoutResult, error) {
+	if req.ExternalID == "" {
+		return nil, errors.New("the external id of the payout must be supplied")
+	}
+	if err := req.Mode.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.TransactionType.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &PayoutResult{}
+
+	// an earlier call may have created the transaction already
+	transaction, err := tc.GetTransactionInformation(ctx, nil, &req.ExternalID)
+	switch {
+	case err == nil:
+		result.Resumed = true
+		result.Transaction = transaction
+		switch class := transactionClass(transaction); {
+		case class == pkg.StatusClassCreated:
+			// created by the earlier call, the payout goes on from there
+		case class.IsConfirmed():
+			// confirmed by the earlier call, there is nothing left to do
+			result.Confirmed = transaction
+			return result, nil
+		case class.IsFailed():
+			return result, &PayoutError{Step: StepLookup, Err: fmt.Errorf("%w: transaction %s is %s", ErrTransactionFailed, req.ExternalID, class)}
+		default:
+			return result, &PayoutError{Step: StepLookup, Err: fmt.Errorf("%w: transaction %s", ErrUnknownStatus, req.ExternalID)}
+		}
+	case isNotFound(err):
+	default:
+		return result, &PayoutError{Step: StepLookup, Err: err}
+	}
+
+	if result.Transaction == nil && !req.SkipValidation {
+		if err = tc.validatePayout(ctx, req); err != nil {
+			return result, &PayoutError{Step: StepValidation, Err: err}
+		}
+	}
+
+	if result.Transaction != nil {
+		err = tc.resumedQuotation(ctx, req, result)
+	} else {
+		result.Quotation, err = tc.payoutQuotation(ctx, req, req.ExternalID)
+	}
+	if err != nil {
+		return result, &PayoutError{Step: StepQuotation, Err: err}
+	}
+
+	if result.Transaction == nil {
+		if req.Requote != nil {
+			if err = tc.requote(ctx, req, result, false); err != nil {
+				return result, &PayoutError{Step: StepQuotation, Err: err}
+			}
+		}
+
+		if !req.SkipBalanceCheck {
+			if result.Balance, err = tc.checkBalance(ctx, result.Quotation); err != nil {
+				return result, &PayoutError{Step: StepBalance, Err: err}
+			}
+		}
+
+		transactionReq := req.Transaction
+		transactionReq.ExternalID = &req.ExternalID
+		result.Transaction, err = tc.CreateTransaction(ctx, &transactionReq, &result.Quotation.ID, nil)
+		if errors.Is(err, ErrQuotationExpired) && req.Requote != nil {
+			// the quotation expired meanwhile, e.g. while the balance was checked
+			if err = tc.requote(ctx, req, result, true); err != nil {
+				return result, &PayoutError{Step: StepQuotation, Err: err}
+			}
+			result.Transaction, err = tc.CreateTransaction(ctx, &transactionReq, &result.Quotation.ID, nil)
+		}
+		if err != nil {
+			return result, &PayoutError{Step: StepTransaction, Err: err}
+		}
+	}
+
+	if err = tc.payoutAttachments(ctx, req, result); err != nil {
+		tc.compensate(result)
+		return result, &PayoutError{Step: StepAttachment, Err: err}
+	}
+
+	if result.Confirmed, err = tc.ConfirmTransaction(ctx, result.Transaction.ID, nil); err != nil {
+		// the confirmation may have gone through even though its response was lost
+		if transaction, lookupErr := tc.GetTransactionInformation(ctx, result.Transaction.ID, nil); lookupErr == nil {
+			switch class := transactionClass(transaction); {
+			case class.IsConfirmed():
+				result.Confirmed = transaction
+				return result, nil
+			case class.IsFailed():
+				result.Transaction = transaction
+				return result, &PayoutError{Step: StepConfirm, Err: fmt.Errorf("%w: transaction %s is %s: %v", ErrTransactionFailed, req.ExternalID, class, err)}
+			}
+		}
+
+		tc.compensate(result)
+		return result, &PayoutError{Step: StepConfirm, Err: err}
+	}
+
+	return result, nil
+}
+
+// transactionClass returns the status class of the transaction, taken from its status when the class is missing or empty,
+// so that SendMoney and the poller classify a transaction the same way.
+// It is empty when neither is known.
+func transactionClass(transaction *pkg.Transaction) pkg.StatusClass {
+	if transaction.StatusClass != nil && *transaction.StatusClass != "" {
+		return *transaction.StatusClass
+	}
+	if transaction.Status != nil {
+		return transaction.Status.Class()
+	}
+	return ""
+}
+
+// validatePayout checks the transaction of the payout against the requirements of its payer,
+// so that an incomplete transaction fails before anything is created.
+func (tc *ThunesClient) validatePayout(ctx context.Context, req *PayoutRequest) error {
+	payer, err := tc.GetPayerDetails(ctx, req.PayerID)
+	if err != nil {
+		return err
+	}
+
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	return pkg.Validate(payer, req.TransactionType, &transactionReq)
+}
+
+// payoutQuotation creates the quotation of the payout with the external id, or retrieves the one created by an earlier call.
+// A quotation of the external id created for another payer, mode, currency or amount fails with ErrDuplicateExternalID.
+func (tc *ThunesClient) payoutQuotation(ctx context.Context, req *PayoutRequest, externalID string) (*pkg.Quotation, error) {
+	amount := req.Amount
+	quotationReq := pkg.CreateQuotationRequest{
+		ExternalID:      externalID,
+		PayerID:         strconv.Itoa(req.PayerID),
+		Mode:            req.Mode,
+		TransactionType: req.TransactionType,
+		Source: pkg.Source{
+			Currency:       req.SourceCurrency,
+			CountryISOCode: req.SourceCountryISOCode,
+		},
+		Destination: pkg.CurrencyAmount{
+			Currency: req.DestinationCurrency,
+		},
+	}
+	if req.Mode == SourceAmount {
+		quotationReq.Source.Amount = &amount
+	} else {
+		quotationReq.Destination.Amount = &amount
+	}
+
+	quotation, err := tc.createQuotation(ctx, &quotationReq)
+	if errors.Is(err, ErrDuplicateExternalID) {
+		existing, lookupErr := tc.GetQuotationByExternalID(ctx, externalID)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if !sameQuotation(existing, &quotationReq) {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	return quotation, err
+}
+
+// resumedQuotation finds the quotation the transaction of a resumed payout was created from: the quotation of
+// the external id of the payout, or one of the suffixed quotations which replaced it. The quotations before it
+// are recorded as expired.
+func (tc *ThunesClient) resumedQuotation(ctx context.Context, req *PayoutRequest, result *PayoutResult) error {
+	quotation, err := tc.GetQuotationByExternalID(ctx, req.ExternalID)
+	if err != nil {
+		return err
+	}
+	quotations := []*pkg.Quotation{quotation}
+
+	for n := 1; n <= maxRequotes; n++ {
+		quotation, err = tc.GetQuotationByExternalID(ctx, fmt.Sprintf("%s-r%d", req.ExternalID, n))
+		if isNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		quotations = append(quotations, quotation)
+	}
+
+	// the transaction copies the amounts and the rate of its quotation, the latest one matching is the one
+	// it was created from; a quotation created after it, e.g. by a call which did not see it yet, was never used
+	i := len(quotations) - 1
+	for j := i; j >= 0; j-- {
+		if createdFrom(result.Transaction, quotations[j]) {
+			i = j
+			break
+		}
+	}
+
+	result.Quotation = quotations[i]
+	result.ExpiredQuotations = quotations[:i]
+	return nil
+}
+
+// createdFrom reports whether the amount sent and the rate of the transaction are the ones of the quotation.
+func createdFrom(transaction *pkg.Transaction, quotation *pkg.Quotation) bool {
+	if transaction.SendAmount != nil && !transaction.SendAmount.Equal(quotation.SentAmount) {
+		return false
+	}
+	if transaction.WholeSaleFXRate != nil && !transaction.WholeSaleFXRate.Equal(quotation.WholeSaleFXRate) {
+		return false
+	}
+	return true
+}
+
+// requote replaces the quotation of the payout until one has at least the minimum time left, or unconditionally once
+// if expired is set. Each quotation gets the next suffixed external id, so that a resumed payout finds the ones
+// created by an earlier call. It fails if the rate drifted too far from the one of the first quotation.
+func (tc *ThunesClient) requote(ctx context.Context, req *PayoutRequest, result *PayoutResult, expired bool) error {
+	first := result.Quotation
+	if len(result.ExpiredQuotations) > 0 {
+		first = result.ExpiredQuotations[0]
+	}
+
+	for expired || result.Quotation.TimeLeft() < req.Requote.MinTimeLeft {
+		n := len(result.ExpiredQuotations) + 1
+		if n > maxRequotes {
+			return fmt.Errorf("%w: still expiring after %d quotations", ErrQuotationExpired, maxRequotes)
+		}
+
+		quotation, err := tc.payoutQuotation(ctx, req, fmt.Sprintf("%s-r%d", req.ExternalID, n))
+		if err != nil {
+			return err
+		}
+		result.ExpiredQuotations = append(result.ExpiredQuotations, result.Quotation)
+		result.Quotation = quotation
+		expired = false
+
+		// the rate may drift by MaxRateDrift times the first rate either way
+		from, to := first.WholeSaleFXRate, quotation.WholeSaleFXRate
+		if to.Sub(from).Abs().Cmp(from.Abs().Mul(req.Requote.MaxRateDrift)) > 0 {
+			return fmt.Errorf("%w: wholesale FX rate went from %s to %s, more than %s", ErrRateDrift, from, to, req.Requote.MaxRateDrift)
+		}
+	}
+
+	return nil
+}
+
+// checkBalance ensures the balance of the source currency covers the amount sent, fee included.
+func (tc *ThunesClient) checkBalance(ctx context.Context, quotation *pkg.Quotation) (*pkg.Balance, error) {
+	balances, err := tc.AllBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := quotation.SentAmount
+	for i := range balances {
+		if balances[i].Currency != sent.Currency {
+			continue
+		}
+
+		if balances[i].Available.Cmp(sent.Amount) < 0 {
+			return &balances[i], fmt.Errorf("%w: %s %s available, %s needed", ErrInsufficientBalance, balances[i].Available, sent.Currency, sent)
+		}
+		return &balances[i], nil
+	}
+
+	return nil, fmt.Errorf("%w: no %s balance", ErrInsufficientBalance, sent.Currency)
+}
+
+// payoutAttachments adds the attachments of the payout to its transaction,
+// skipping the ones already added by an earlier call.
+func (tc *ThunesClient) payoutAttachments(ctx context.Context, req *PayoutRequest, result *PayoutResult) error {
+	if len(req.Attachments) == 0 {
+		return nil
+	}
+
+	attachments := req.Attachments
+	if result.Resumed {
+		existing, err := tc.ListTransactionAttachments(ctx, result.Transaction.ID, nil)
+		if err != nil {
+			return err
+		}
+		result.Attachments = existing
+
+		if len(existing) >= len(attachments) {
+			return nil
+		}
+		attachments = attachments[len(existing):]
+	}
+
+	for _, attachment := range attachments {
+		added, err := tc.AddAttachmentToTransaction(ctx, attachment.Name, attachment.Type, attachment.File, result.Transaction.ID, nil)
+		if err != nil {
+			return err
+		}
+		result.Attachments = append(result.Attachments, *added)
+	}
+
+	return nil
+}
+
+// compensate cancels the transaction of a failed payout. Failing to cancel is not reported:
+// an unconfirmed transaction is never paid out and expires on its own.
+func (tc *ThunesClient) compensate(result *PayoutResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+
+	if cancelled, err := tc.CancelTransaction(ctx, result.Transaction.ID, nil); err == nil {
+		result.Cancelled = cancelled
+	}
+}
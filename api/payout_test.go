@@ -0,0 +1,299 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// payoutRequest returns a valid payout of 100 USD to the M-Pesa payer of the default fixtures.
+func payoutRequest(externalID string) *api.PayoutRequest {
+	return &api.PayoutRequest{
+		ExternalID:           externalID,
+		PayerID:              1,
+		TransactionType:      pkg.C2C,
+		SourceCurrency:       "USD",
+		SourceCountryISOCode: "USA",
+		DestinationCurrency:  "KES",
+		Mode:                 api.SourceAmount,
+		Amount:               pkg.DecimalFromInt(100),
+		Transaction: pkg.CreateTransactionRequest{
+			CreditPartyIdentifier: &pkg.CreditPartyIdentifier{MSISDN: "254700000001"},
+			Sender: &pkg.Sender{
+				LastName:       strPtr("Doe"),
+				FirstName:      strPtr("John"),
+				CountryISOCode: strPtr("USA"),
+			},
+			Beneficiary: &pkg.Beneficiary{
+				LastName:  strPtr("Wanjiru"),
+				FirstName: strPtr("Jane"),
+			},
+		},
+	}
+}
+
+func newServer(t *testing.T) (*thunestest.Server, *api.ThunesClient) {
+	t.Helper()
+	srv := thunestest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, srv.Client(api.WithRetryPolicy(api.NoRetry))
+}
+
+func TestSendMoney(t *testing.T) {
+	srv, tc := newServer(t)
+
+	result, err := tc.SendMoney(context.Background(), payoutRequest("payout-1"))
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if result.Resumed {
+		t.Error("Resumed = true, want false")
+	}
+	if result.Confirmed == nil || *result.Confirmed.Status != pkg.StatusConfirmed {
+		t.Fatalf("Confirmed = %+v, want a CONFIRMED transaction", result.Confirmed)
+	}
+
+	transaction, _ := srv.Transaction(*result.Confirmed.ID)
+	if *transaction.Status != pkg.StatusConfirmed {
+		t.Errorf("server status = %s, want %s", *transaction.Status, pkg.StatusConfirmed)
+	}
+}
+
+func TestSendMoneyResumesCreatedTransaction(t *testing.T) {
+	srv, tc := newServer(t)
+	ctx := context.Background()
+
+	// the first call created the transaction and failed to confirm it without compensating, e.g. it crashed
+	req := payoutRequest("payout-1")
+	quotation, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	created, err := tc.CreateTransaction(ctx, &transactionReq, &quotation.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tc.SendMoney(ctx, req)
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if !result.Resumed {
+		t.Error("Resumed = false, want true")
+	}
+	if *result.Confirmed.ID != *created.ID {
+		t.Errorf("confirmed transaction %d, want %d", *result.Confirmed.ID, *created.ID)
+	}
+	if result.Quotation.ID != quotation.ID {
+		t.Errorf("quotation %d, want %d", result.Quotation.ID, quotation.ID)
+	}
+
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/v2/money-transfer/transactions" {
+			t.Errorf("a transaction was created again: %s %s", r.Method, r.Path)
+		}
+	}
+}
+
+func TestSendMoneyResumedStatus(t *testing.T) {
+	tests := []struct {
+		status pkg.TransactionStatus
+		err    error
+	}{
+		{status: pkg.StatusConfirmed},
+		{status: pkg.StatusSubmitted},
+		{status: pkg.StatusCompleted},
+		{status: pkg.StatusRejected, err: api.ErrTransactionFailed},
+		{status: pkg.StatusCancelled, err: api.ErrTransactionFailed},
+		{status: pkg.StatusDeclined, err: api.ErrTransactionFailed},
+		{status: pkg.StatusReversed, err: api.ErrTransactionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			srv, tc := newServer(t)
+			ctx := context.Background()
+
+			first, err := tc.SendMoney(ctx, payoutRequest("payout-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := srv.SetTransactionStatus(*first.Confirmed.ID, tt.status); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tc.SendMoney(ctx, payoutRequest("payout-1"))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SendMoney() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				var payoutErr *api.PayoutError
+				if !errors.As(err, &payoutErr) || payoutErr.Step != api.StepLookup {
+					t.Errorf("SendMoney() error = %v, want a PayoutError of the lookup step", err)
+				}
+				if result.Confirmed != nil {
+					t.Errorf("Confirmed = %+v, want nil", result.Confirmed)
+				}
+				return
+			}
+			if !result.Resumed || result.Confirmed == nil || *result.Confirmed.Status != tt.status {
+				t.Errorf("result = %+v, want the resumed %s transaction", result, tt.status)
+			}
+		})
+	}
+}
+
+func TestSendMoneyCompensatesFailedConfirmation(t *testing.T) {
+	srv, tc := newServer(t)
+
+	// the only POST request to a transaction before the compensation is the confirmation
+	srv.InjectFault(thunestest.Fault{
+		Method:     http.MethodPost,
+		Path:       "/v2/money-transfer/transactions/",
+		StatusCode: http.StatusInternalServerError,
+		Times:      1,
+	})
+
+	result, err := tc.SendMoney(context.Background(), payoutRequest("payout-1"))
+	var payoutErr *api.PayoutError
+	if !errors.As(err, &payoutErr) || payoutErr.Step != api.StepConfirm {
+		t.Fatalf("SendMoney() error = %v, want a PayoutError of the confirm step", err)
+	}
+	if result.Confirmed != nil {
+		t.Errorf("Confirmed = %+v, want nil", result.Confirmed)
+	}
+
+	transaction, _ := srv.Transaction(*result.Transaction.ID)
+	if *transaction.Status != pkg.StatusCancelled {
+		t.Errorf("server status = %s, want %s", *transaction.Status, pkg.StatusCancelled)
+	}
+}
+
+func TestSendMoneyLostConfirmation(t *testing.T) {
+	srv, tc := newServer(t)
+
+	// the confirmation goes through but its response is lost
+	srv.InjectFault(thunestest.Fault{
+		Method:        http.MethodPost,
+		Path:          "/v2/money-transfer/transactions/",
+		StatusCode:    http.StatusBadGateway,
+		AfterHandling: true,
+		Times:         1,
+	})
+
+	result, err := tc.SendMoney(context.Background(), payoutRequest("payout-1"))
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if result.Confirmed == nil || *result.Confirmed.Status != pkg.StatusConfirmed {
+		t.Fatalf("Confirmed = %+v, want a CONFIRMED transaction", result.Confirmed)
+	}
+
+	transaction, _ := srv.Transaction(*result.Confirmed.ID)
+	if *transaction.Status != pkg.StatusConfirmed {
+		t.Errorf("server status = %s, want %s", *transaction.Status, pkg.StatusConfirmed)
+	}
+}
+
+func TestSendMoneyResumesRequotedTransaction(t *testing.T) {
+	srv, tc := newServer(t)
+	ctx := context.Background()
+
+	// the first call re-created the expired quotation, and created the transaction from the new one
+	req := payoutRequest("payout-1")
+	expired, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requoted, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID+"-r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	if _, err := tc.CreateTransaction(ctx, &transactionReq, &requoted.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tc.SendMoney(ctx, req)
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if result.Quotation.ID != requoted.ID {
+		t.Errorf("quotation %d, want the re-created quotation %d", result.Quotation.ID, requoted.ID)
+	}
+	if len(result.ExpiredQuotations) != 1 || result.ExpiredQuotations[0].ID != expired.ID {
+		t.Errorf("ExpiredQuotations = %+v, want the quotation %d", result.ExpiredQuotations, expired.ID)
+	}
+	created := 0
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/v2/money-transfer/quotations" {
+			created++
+		}
+	}
+	if created != 2 {
+		t.Errorf("%d quotations created, want the 2 of the first call", created)
+	}
+}
+
+func TestSendMoneyReusedExternalID(t *testing.T) {
+	srv, tc := newServer(t)
+	ctx := context.Background()
+
+	// a quotation of another amount was created with the external id of the payout, without a transaction
+	req := payoutRequest("payout-1")
+	if _, err := tc.CreateQuotationForSource(ctx, pkg.DecimalFromInt(200), "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tc.SendMoney(ctx, req)
+	if !errors.Is(err, api.ErrDuplicateExternalID) {
+		t.Fatalf("SendMoney() error = %v, want ErrDuplicateExternalID", err)
+	}
+	var payoutErr *api.PayoutError
+	if !errors.As(err, &payoutErr) || payoutErr.Step != api.StepQuotation {
+		t.Errorf("SendMoney() error = %v, want a PayoutError of the quotation step", err)
+	}
+	if result.Quotation != nil {
+		t.Errorf("Quotation = %+v, want nil", result.Quotation)
+	}
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/v2/money-transfer/transactions" {
+			t.Errorf("a transaction was created: %s %s", r.Method, r.Path)
+		}
+	}
+}
+
+func TestSendMoneyResumesQuotation(t *testing.T) {
+	srv, tc := newServer(t)
+	ctx := context.Background()
+
+	// the first call created the quotation of the payout and stopped before creating the transaction
+	req := payoutRequest("payout-1")
+	quotation, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tc.SendMoney(ctx, req)
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	if result.Quotation.ID != quotation.ID {
+		t.Errorf("quotation %d, want %d", result.Quotation.ID, quotation.ID)
+	}
+	if n := countRequests(srv, http.MethodPost, "/v2/money-transfer/quotations"); n != 2 {
+		t.Errorf("%d quotation creations, want the 2 of both calls", n)
+	}
+}
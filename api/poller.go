@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+const (
+	// DefaultPollInterval is the delay between two polls of a transaction right after it changed status.
+	DefaultPollInterval = 2 * time.Second
+	// DefaultMaxPollInterval caps the delay between two polls, which doubles while the status does not change.
+	DefaultMaxPollInterval = time.Minute
+)
+
+// WatchOption configures how a transaction is polled by Watch and WaitForFinalStatus.
+type WatchOption func(*watcher)
+
+// PollInterval sets the initial and the maximum delay between two polls.
+// The delay starts at initial, doubles after every poll which sees no change, up to max,
+// and goes back to initial when the status changes.
+func PollInterval(initial, max time.Duration) WatchOption {
+	return func(w *watcher) {
+		if initial > 0 {
+			w.initial = initial
+		}
+		if max >= w.initial {
+			w.max = max
+		} else {
+			w.max = w.initial
+		}
+	}
+}
+
+// StatusChange reports a transition of a watched transaction.
+type StatusChange struct {
+	// Transaction is the transaction as polled right after the transition.
+	Transaction *pkg.Transaction
+	// Previous is the status before the transition, empty for the status observed by the first poll.
+	Previous pkg.TransactionStatus
+	// Err is set on the last value sent when polling stopped on an error.
+	Err error
+}
+
+type watcher struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+// Watch polls a transaction until it reaches a final status class and sends every status change on the
+// returned channel, starting with the status found by the first poll. The channel is closed once a final
+// status has been sent, or after a StatusChange with Err set when polling fails.
+//
+// Transient failures, e.g. timeouts or 5xx responses, do not stop the watch: the transaction is polled
+// again after the next delay. Cancelling ctx stops the watch and closes the channel without sending an
+// error; the caller must either drain the channel or cancel ctx.
+// Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
+func (tc *ThunesClient) Watch(ctx context.Context, id *int, externalID *string, opts ...WatchOption) <-chan StatusChange {
+	w := &watcher{initial: DefaultPollInterval, max: DefaultMaxPollInterval}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	changes := make(chan StatusChange)
+	go func() {
+		defer close(changes)
+		w.run(ctx, tc, id, externalID, changes)
+	}()
+	return changes
+}
+
+func (w *watcher) run(ctx context.Context, tc *ThunesClient, id *int, externalID *string, changes chan<- StatusChange) {
+	send := func(change StatusChange) bool {
+		select {
+		case changes <- change:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var previous pkg.TransactionStatus
+	interval := w.initial
+	for {
+		transaction, err := tc.GetTransactionInformation(ctx, id, externalID)
+		if err == nil && transaction.Status == nil {
+			err = errors.New("thunes: transaction has no status")
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil && !isRetryable(err):
+			send(StatusChange{Err: err})
+			return
+		case err == nil && *transaction.Status != previous:
+			if !send(StatusChange{Transaction: transaction, Previous: previous}) {
+				return
+			}
+			if transactionClass(transaction).IsFinal() {
+				return
+			}
+			previous = *transaction.Status
+			interval = w.initial
+		default:
+			// nothing changed, or a transient failure: slow down
+			interval *= 2
+			if interval > w.max {
+				interval = w.max
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// WaitForFinalStatus polls a transaction as Watch does and returns it once it reaches a final status class:
+// completed, cancelled, rejected, declined or reversed. On failure, it returns the transaction as last seen,
+// if any, along with the error, which is ctx.Err() if ctx is done first.
+// Either the ID or the externalID of the transaction must be supplied. If both are supplied, the ID will be used.
+func (tc *ThunesClient) WaitForFinalStatus(ctx context.Context, id *int, externalID *string, opts ...WatchOption) (*pkg.Transaction, error) {
+	if id == nil && externalID == nil {
+		return nil, errors.New("either the ID or the externalID of the transaction must be supplied")
+	}
+
+	var last *pkg.Transaction
+	for change := range tc.Watch(ctx, id, externalID, opts...) {
+		if change.Err != nil {
+			return last, change.Err
+		}
+		last = change.Transaction
+	}
+
+	if last == nil || !transactionClass(last).IsFinal() {
+		return last, ctx.Err()
+	}
+	return last, nil
+}
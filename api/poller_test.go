@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+// fastPolls polls every millisecond, so that the tests do not wait between two polls.
+var fastPolls = api.PollInterval(time.Millisecond, 2*time.Millisecond)
+
+// sendPayout sends a payout and returns the id of its confirmed transaction.
+func sendPayout(t *testing.T, tc *api.ThunesClient, externalID string) int {
+	t.Helper()
+	result, err := tc.SendMoney(context.Background(), payoutRequest(externalID))
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+	return *result.Confirmed.ID
+}
+
+// nextChange receives the next status change, failing the test if none comes.
+func nextChange(t *testing.T, changes <-chan api.StatusChange) api.StatusChange {
+	t.Helper()
+	select {
+	case change, ok := <-changes:
+		if !ok {
+			t.Fatal("the watch stopped")
+		}
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no status change")
+	}
+	return api.StatusChange{}
+}
+
+func TestWatch(t *testing.T) {
+	srv, tc := newServer(t)
+	id := sendPayout(t, tc, "payout-1")
+
+	changes := tc.Watch(context.Background(), &id, nil, fastPolls)
+
+	change := nextChange(t, changes)
+	if *change.Transaction.Status != pkg.StatusConfirmed || change.Previous != "" {
+		t.Errorf("first change = %s from %q, want the confirmed status", *change.Transaction.Status, change.Previous)
+	}
+
+	for _, status := range []pkg.TransactionStatus{pkg.StatusSubmitted, pkg.StatusCompleted} {
+		previous := *change.Transaction.Status
+		if err := srv.SetTransactionStatus(id, status); err != nil {
+			t.Fatal(err)
+		}
+		change = nextChange(t, changes)
+		if change.Err != nil || *change.Transaction.Status != status || change.Previous != previous {
+			t.Errorf("change = %+v, want %s from %s", change, status, previous)
+		}
+	}
+
+	select {
+	case change, ok := <-changes:
+		if ok {
+			t.Errorf("change %+v sent after the final status", change)
+		}
+	case <-time.After(time.Second):
+		t.Error("the channel was not closed after the final status")
+	}
+}
+
+func TestWatchPollsThroughTransientErrors(t *testing.T) {
+	srv, tc := newServer(t)
+	id := sendPayout(t, tc, "payout-1")
+	if err := srv.SetTransactionStatus(id, pkg.StatusCompleted); err != nil {
+		t.Fatal(err)
+	}
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/transactions", StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	transaction, err := tc.WaitForFinalStatus(context.Background(), &id, nil, fastPolls)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() error = %v", err)
+	}
+	if *transaction.Status != pkg.StatusCompleted {
+		t.Errorf("Status = %s, want %s", *transaction.Status, pkg.StatusCompleted)
+	}
+}
+
+func TestWatchStopsOnError(t *testing.T) {
+	_, tc := newServer(t)
+	id := 42
+
+	changes := tc.Watch(context.Background(), &id, nil, fastPolls)
+	change := nextChange(t, changes)
+	var apiErr *api.APIError
+	if !errors.As(change.Err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Err = %v, want a 404 APIError", change.Err)
+	}
+	if _, ok := <-changes; ok {
+		t.Error("the watch went on after the error")
+	}
+}
+
+func TestWaitForFinalStatusByExternalID(t *testing.T) {
+	srv, tc := newServer(t)
+	id := sendPayout(t, tc, "payout-1")
+
+	done := make(chan error, 1)
+	go func() {
+		transaction, err := tc.WaitForFinalStatus(context.Background(), nil, strPtr("payout-1"), fastPolls)
+		if err == nil && *transaction.Status != pkg.StatusDeclined {
+			err = errors.New("not declined: " + string(*transaction.Status))
+		}
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := srv.SetTransactionStatus(id, pkg.StatusDeclined); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("WaitForFinalStatus() error = %v", err)
+	}
+}
+
+func TestWaitForFinalStatusCancelled(t *testing.T) {
+	_, tc := newServer(t)
+	id := sendPayout(t, tc, "payout-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	transaction, err := tc.WaitForFinalStatus(ctx, &id, nil, fastPolls)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForFinalStatus() error = %v, want context.DeadlineExceeded", err)
+	}
+	if transaction == nil || *transaction.Status != pkg.StatusConfirmed {
+		t.Errorf("transaction = %+v, want the confirmed transaction last seen", transaction)
+	}
+
+	if _, err := tc.WaitForFinalStatus(context.Background(), nil, nil); err == nil {
+		t.Error("WaitForFinalStatus() without an id succeeded")
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// EndpointGroup identifies a family of endpoints sharing a rate limit.
+type EndpointGroup string
+
+const (
+	// GroupCatalog holds the reads of services, payers, rates and countries, and the BIC lookups.
+	GroupCatalog = EndpointGroup("catalog")
+	// GroupQuotations holds the creation and the reads of quotations.
+	GroupQuotations = EndpointGroup("quotations")
+	// GroupTransactions holds the creation, confirmation, cancellation and reads of transactions and their attachments.
+	GroupTransactions = EndpointGroup("transactions")
+	// GroupOther holds the remaining endpoints: ping, balances and the credit party checks.
+	GroupOther = EndpointGroup("other")
+)
+
+// endpointGroups lists every endpoint group.
+var endpointGroups = []EndpointGroup{GroupCatalog, GroupQuotations, GroupTransactions, GroupOther}
+
+// endpointGroup returns the group of the endpoint at url, relative to the base url.
+func endpointGroup(url string) EndpointGroup {
+	switch endpointOf(url) {
+	case EndpointServices, EndpointPayers, EndpointRates, EndpointCountries, EndpointLookups:
+		return GroupCatalog
+	case EndpointQuotations:
+		return GroupQuotations
+	case EndpointTransactions, EndpointAttachments:
+		return GroupTransactions
+	}
+	return GroupOther
+}
+
+// RateLimit is the limit of a token bucket: Rate requests per second on average, in bursts of up to Burst requests.
+//
+// The limit adapts to the 429 responses of the API: the rate is halved, down to a sixteenth of Rate,
+// every time requests of the group are throttled, and recovers linearly to Rate over RateLimitRecovery.
+// The group is paused for the duration of their Retry-After header, if any.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+const (
+	// RateLimitRecovery is how long a rate limit halved after a 429 response takes to recover its full rate.
+	RateLimitRecovery = time.Minute
+
+	// slowdownFactor is applied to the rate of a group on 429 responses, minRateFactor bounds the slowdown.
+	slowdownFactor = 0.5
+	minRateFactor  = 1.0 / 16
+	// slowdownWindow is the period in which 429 responses slow a group down only once,
+	// the concurrent requests sent at the previous rate are likely to be throttled as well.
+	slowdownWindow = time.Second
+)
+
+// WithRateLimit limits the rate of the requests of an endpoint group, shared by every goroutine using the client.
+// Retries count as requests. The groups without a rate limit only honour the Retry-After header of 429 responses.
+func WithRateLimit(group EndpointGroup, limit RateLimit) Option {
+	return func(tc *ThunesClient) {
+		if b, ok := tc.limiter.buckets[group]; ok && limit.Rate > 0 {
+			b.setLimit(limit)
+		}
+	}
+}
+
+// WithMaxInFlight caps the number of requests in progress at the same time, zero means no cap.
+func WithMaxInFlight(n int) Option {
+	return func(tc *ThunesClient) {
+		tc.limiter.inflight = nil
+		if n > 0 {
+			tc.limiter.inflight = make(chan struct{}, n)
+		}
+	}
+}
+
+// limiter applies the rate limits and the in-flight cap of a client.
+type limiter struct {
+	// buckets holds the bucket of every group, the map is not modified once the client is constructed
+	buckets  map[EndpointGroup]*bucket
+	inflight chan struct{}
+}
+
+func newLimiter() *limiter {
+	l := &limiter{buckets: make(map[EndpointGroup]*bucket, len(endpointGroups))}
+	for _, group := range endpointGroups {
+		l.buckets[group] = &bucket{now: time.Now}
+	}
+	return l
+}
+
+// acquire waits until a request of the group can be sent, and returns the function to call once it is done.
+func (l *limiter) acquire(ctx context.Context, group EndpointGroup) (func(), error) {
+	b := l.buckets[group]
+	if wait := b.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			b.cancel()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if l.inflight == nil {
+		return func() {}, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case l.inflight <- struct{}{}:
+		return func() { <-l.inflight }, nil
+	}
+}
+
+// throttled slows the group down after a 429 response.
+func (l *limiter) throttled(group EndpointGroup, retryAfter time.Duration) {
+	l.buckets[group].throttle(retryAfter)
+}
+
+// bucket is the token bucket of an endpoint group. Without a limit it only enforces the pauses.
+type bucket struct {
+	mu    sync.Mutex
+	limit RateLimit
+	// rate is the current rate, below the limit after 429 responses
+	rate   float64
+	tokens float64
+	// last is when the tokens were last refilled
+	last time.Time
+	// paused is when the requests may be sent again after a Retry-After
+	paused time.Time
+	// slowed is when the rate was last lowered
+	slowed time.Time
+	now    func() time.Time
+}
+
+func (b *bucket) setLimit(limit RateLimit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	b.limit = limit
+	b.rate = limit.Rate
+	b.tokens = float64(limit.Burst)
+	b.last = b.now()
+}
+
+// refill adds the tokens earned since the last refill, and recovers the rate lowered by 429 responses.
+// It must be called with b.mu held.
+func (b *bucket) refill(t time.Time) {
+	elapsed := t.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.last = t
+
+	if b.rate < b.limit.Rate {
+		b.rate = math.Min(b.limit.Rate, b.rate+b.limit.Rate*float64(elapsed)/float64(RateLimitRecovery))
+	}
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+b.rate*elapsed.Seconds())
+}
+
+// reserve takes a token and returns how long to wait before sending the request.
+func (b *bucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	start := now
+	if b.paused.After(start) {
+		start = b.paused
+	}
+	if b.limit.Rate <= 0 {
+		return start.Sub(now)
+	}
+
+	b.refill(start)
+	b.tokens--
+	wait := start.Sub(now)
+	if b.tokens < 0 {
+		wait += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return wait
+}
+
+// cancel gives back the token of a request which was not sent.
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit.Rate > 0 {
+		b.tokens++
+	}
+}
+
+// throttle pauses the group for retryAfter and lowers its rate.
+func (b *bucket) throttle(retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if until := now.Add(retryAfter); until.After(b.paused) {
+		b.paused = until
+	}
+	if b.limit.Rate <= 0 || now.Sub(b.slowed) < slowdownWindow {
+		return
+	}
+
+	b.refill(now)
+	b.slowed = now
+	b.rate = math.Max(b.rate*slowdownFactor, b.limit.Rate*minRateFactor)
+	// the burst saved at the previous rate would be throttled too
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+}
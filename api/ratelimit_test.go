@@ -0,0 +1,150 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/thunestest"
+)
+
+// getBalances gets the balances n times in a row and returns how long it took.
+func getBalances(t *testing.T, tc *api.ThunesClient, n int) time.Duration {
+	t.Helper()
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		if _, err := tc.AllBalances(context.Background()); err != nil {
+			t.Fatalf("AllBalances() error = %v", err)
+		}
+	}
+	return time.Since(start)
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, _ := newServer(t)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithRateLimit(api.GroupOther, api.RateLimit{Rate: 50, Burst: 2}))
+
+	// the burst goes through at once, the next requests wait 20ms each
+	if elapsed := getBalances(t, tc, 6); elapsed < 80*time.Millisecond {
+		t.Errorf("6 requests took %v, want at least 80ms", elapsed)
+	}
+
+	// the other groups have their own bucket
+	start := time.Now()
+	if _, err := tc.AllCountries(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("a catalog request waited %v for the balances", elapsed)
+	}
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	srv, _ := newServer(t)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithRateLimit(api.GroupOther, api.RateLimit{Rate: 1, Burst: 1}))
+	getBalances(t, tc, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tc.AllBalances(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AllBalances() error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := countRequests(srv, http.MethodGet, "/v2/money-transfer/balances"); n != 1 {
+		t.Errorf("%d requests sent, want the cancelled one held back", n)
+	}
+}
+
+func TestThrottledSlowsDown(t *testing.T) {
+	srv, _ := newServer(t)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithRateLimit(api.GroupOther, api.RateLimit{Rate: 100, Burst: 1}))
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/balances", StatusCode: http.StatusTooManyRequests, Times: 1})
+
+	if _, err := tc.AllBalances(context.Background()); err == nil {
+		t.Fatal("AllBalances() succeeded despite the 429 response")
+	}
+
+	// at half the rate, 5 requests without a token take 100ms rather than 50ms
+	if elapsed := getBalances(t, tc, 5); elapsed < 90*time.Millisecond {
+		t.Errorf("5 requests after a 429 took %v, want about 100ms", elapsed)
+	}
+}
+
+func TestThrottledHonoursRetryAfter(t *testing.T) {
+	srv, _ := newServer(t)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry))
+	srv.InjectFault(thunestest.Fault{
+		Method:     http.MethodGet,
+		Path:       "/v2/money-transfer/balances",
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"1"}},
+		Times:      1,
+	})
+
+	if _, err := tc.AllBalances(context.Background()); err == nil {
+		t.Fatal("AllBalances() succeeded despite the 429 response")
+	}
+
+	// a group without a rate limit is paused too, the other groups are not
+	start := time.Now()
+	if _, err := tc.AllCountries(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("a catalog request was paused for %v", elapsed)
+	}
+	if elapsed := getBalances(t, tc, 1); elapsed < 900*time.Millisecond {
+		t.Errorf("the request after Retry-After: 1 waited %v, want about 1s", elapsed)
+	}
+}
+
+// concurrencyTransport counts the requests in progress at the same time.
+type concurrencyTransport struct {
+	mu       sync.Mutex
+	current  int
+	max      int
+	delay    time.Duration
+	delegate http.RoundTripper
+}
+
+func (c *concurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.current--
+		c.mu.Unlock()
+	}()
+	time.Sleep(c.delay)
+	return c.delegate.RoundTrip(req)
+}
+
+func TestMaxInFlight(t *testing.T) {
+	srv, _ := newServer(t)
+	transport := &concurrencyTransport{delay: 10 * time.Millisecond, delegate: http.DefaultTransport}
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithHTTPClient(&http.Client{Transport: transport}), api.WithMaxInFlight(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tc.AllBalances(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if transport.max != 2 {
+		t.Errorf("%d requests in flight at most, want 2", transport.max)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// RetryPolicy describes how failed requests are retried.
+//
+// Only requests which are safe to replay are retried: idempotent GET requests, and quotation
+// and transaction creations carrying an external id. A request is retried on transport errors
+// and on 429 and 5xx responses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one, values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it doubles on every following retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts. A Retry-After header asking
+	// for a longer wait stops the retries instead.
+	MaxBackoff time.Duration
+	// Jitter is the fraction in [0, 1] of every wait which is randomised to spread retries of concurrent callers.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the retry policy of a client constructed without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Jitter:         0.5,
+}
+
+// NoRetry disables retries.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(tc *ThunesClient) {
+		tc.retryPolicy = policy
+	}
+}
+
+// backoff returns how long to wait before the attempt following the given one,
+// and false if no further attempt must be made.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	wait := time.Duration(float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1)))
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+
+	// randomise the lower part of the wait
+	if p.Jitter > 0 {
+		jitter := time.Duration(math.Min(p.Jitter, 1) * float64(wait))
+		if jitter > 0 {
+			wait = wait - jitter + time.Duration(rand.Int63n(int64(jitter)+1))
+		}
+	}
+
+	// the server knows best when it is ready again
+	if retryAfter > wait {
+		if p.MaxBackoff > 0 && retryAfter > p.MaxBackoff {
+			return 0, false
+		}
+		wait = retryAfter
+	}
+
+	return wait, true
+}
+
+// isRetryable reports whether err is a transient failure worth another attempt.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	// the http client reports transport failures, e.g. a connection reset or its own timeout, as *url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryAfter parses the Retry-After header, which holds either a number of seconds or an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// sameQuotation reports whether the quotation found by the external id of a replayed request is the one the request
+// creates: same payer, mode, transaction type, currencies and fixed amount. Otherwise the external id was used before.
+func sameQuotation(quotation *pkg.Quotation, req *pkg.CreateQuotationRequest) bool {
+	if strconv.Itoa(quotation.Payer.ID) != req.PayerID || quotation.Mode != req.Mode || quotation.TransactionType != req.TransactionType {
+		return false
+	}
+	if !strings.EqualFold(quotation.Source.Currency, req.Source.Currency) || !strings.EqualFold(quotation.Destination.Currency, req.Destination.Currency) {
+		return false
+	}
+
+	if req.Mode == pkg.DestinationAmount {
+		return req.Destination.Amount != nil && quotation.Destination.Amount.Equal(*req.Destination.Amount)
+	}
+	return req.Source.Amount != nil && quotation.Source.Amount != nil && quotation.Source.Amount.Equal(*req.Source.Amount)
+}
+
+// sameTransaction reports whether the transaction found by the external id of a replayed request was created from
+// the quotation of the request: same payer, currencies and amounts. Otherwise the external id was used before.
+func sameTransaction(transaction *pkg.Transaction, quotation *pkg.Quotation) bool {
+	if transaction.Payer == nil || transaction.Payer.ID != quotation.Payer.ID {
+		return false
+	}
+	if transaction.Source == nil || transaction.Source.Amount == nil || quotation.Source.Amount == nil ||
+		!strings.EqualFold(transaction.Source.Currency, quotation.Source.Currency) || !transaction.Source.Amount.Equal(*quotation.Source.Amount) {
+		return false
+	}
+	return transaction.Destination != nil && transaction.Destination.Equal(quotation.Destination)
+}
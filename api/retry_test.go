@@ -0,0 +1,206 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+// fastRetries retries right away, so that the tests do not wait for the backoff.
+var fastRetries = api.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func newRetryingServer(t *testing.T) (*thunestest.Server, *api.ThunesClient) {
+	t.Helper()
+	srv := thunestest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, srv.Client(api.WithRetryPolicy(fastRetries))
+}
+
+// countRequests returns the number of requests received by the server with the method and path.
+func countRequests(srv *thunestest.Server, method, path string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault thunestest.Fault
+	}{
+		{name: "server error", fault: thunestest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 2}},
+		{name: "throttled", fault: thunestest.Fault{StatusCode: http.StatusTooManyRequests, Times: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, tc := newRetryingServer(t)
+			tt.fault.Method = http.MethodGet
+			tt.fault.Path = "/v2/money-transfer/balances"
+			srv.InjectFault(tt.fault)
+
+			if _, err := tc.AllBalances(context.Background()); err != nil {
+				t.Fatalf("AllBalances() error = %v", err)
+			}
+			if n := countRequests(srv, http.MethodGet, "/v2/money-transfer/balances"); n != 3 {
+				t.Errorf("%d attempts, want 3", n)
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/balances", StatusCode: http.StatusInternalServerError})
+
+	_, err := tc.AllBalances(context.Background())
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("AllBalances() error = %v, want a 500 APIError", err)
+	}
+	if n := countRequests(srv, http.MethodGet, "/v2/money-transfer/balances"); n != fastRetries.MaxAttempts {
+		t.Errorf("%d attempts, want %d", n, fastRetries.MaxAttempts)
+	}
+}
+
+func TestNoRetryOfClientErrors(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+
+	if _, err := tc.GetQuotationByID(context.Background(), 42); err == nil {
+		t.Fatal("GetQuotationByID() of an unknown quotation succeeded")
+	}
+	if n := countRequests(srv, http.MethodGet, "/v2/money-transfer/quotations/42"); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+}
+
+func TestNoRetryOfUnsafeRequests(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	ctx := context.Background()
+
+	req := payoutRequest("payout-1")
+	quotation, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	transaction, err := tc.CreateTransaction(ctx, &transactionReq, &quotation.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a confirmation carries no external id, replaying it is not safe
+	srv.InjectFault(thunestest.Fault{Method: http.MethodPost, Path: "/v2/money-transfer/transactions/", StatusCode: http.StatusBadGateway, Times: 1})
+	if _, err := tc.ConfirmTransaction(ctx, transaction.ID, nil); err == nil {
+		t.Fatal("ConfirmTransaction() succeeded, want the injected error")
+	}
+	if n := countRequests(srv, http.MethodPost, fmt.Sprintf("/v2/money-transfer/transactions/%d/confirm", *transaction.ID)); n != 1 {
+		t.Errorf("%d attempts, want 1", n)
+	}
+}
+
+func TestRecoverLostQuotation(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	srv.InjectFault(thunestest.Fault{
+		Method:        http.MethodPost,
+		Path:          "/v2/money-transfer/quotations",
+		StatusCode:    http.StatusBadGateway,
+		AfterHandling: true,
+		Times:         1,
+	})
+
+	// the first attempt creates the quotation, the replay is rejected as a duplicate
+	quotation, err := tc.CreateQuotationForSource(context.Background(), pkg.DecimalFromInt(100), "KES", "USD", "1", "USA", pkg.C2C, "quotation-1")
+	if err != nil {
+		t.Fatalf("CreateQuotationForSource() error = %v", err)
+	}
+	if quotation.ExternalID != "quotation-1" {
+		t.Errorf("quotation %q, want quotation-1", quotation.ExternalID)
+	}
+	if n := countRequests(srv, http.MethodPost, "/v2/money-transfer/quotations"); n != 2 {
+		t.Errorf("%d attempts, want 2", n)
+	}
+}
+
+func TestRecoverQuotationOfAnotherRequest(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	ctx := context.Background()
+
+	if _, err := tc.CreateQuotationForSource(ctx, pkg.DecimalFromInt(100), "KES", "USD", "1", "USA", pkg.C2C, "quotation-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the external id is reused for another amount, the quotation found is not the one requested
+	srv.InjectFault(thunestest.Fault{Method: http.MethodPost, Path: "/v2/money-transfer/quotations", StatusCode: http.StatusBadGateway, Times: 1})
+	quotation, err := tc.CreateQuotationForSource(ctx, pkg.DecimalFromInt(200), "KES", "USD", "1", "USA", pkg.C2C, "quotation-1")
+	if !errors.Is(err, api.ErrDuplicateExternalID) {
+		t.Fatalf("CreateQuotationForSource() = %+v, %v, want ErrDuplicateExternalID", quotation, err)
+	}
+}
+
+func TestRecoverLostTransaction(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	ctx := context.Background()
+
+	req := payoutRequest("payout-1")
+	quotation, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, req.ExternalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.InjectFault(thunestest.Fault{
+		Method:        http.MethodPost,
+		Path:          "/v2/money-transfer/quotations/",
+		StatusCode:    http.StatusBadGateway,
+		AfterHandling: true,
+		Times:         1,
+	})
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	transaction, err := tc.CreateTransaction(ctx, &transactionReq, &quotation.ID, nil)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if transaction.ExternalID == nil || *transaction.ExternalID != req.ExternalID {
+		t.Errorf("transaction %v, want %s", transaction.ExternalID, req.ExternalID)
+	}
+}
+
+func TestRecoverTransactionOfAnotherQuotation(t *testing.T) {
+	srv, tc := newRetryingServer(t)
+	ctx := context.Background()
+
+	req := payoutRequest("payout-1")
+	first, err := tc.CreateQuotationForSource(ctx, req.Amount, "KES", "USD", "1", "USA", pkg.C2C, "quotation-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionReq := req.Transaction
+	transactionReq.ExternalID = &req.ExternalID
+	if _, err := tc.CreateTransaction(ctx, &transactionReq, &first.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the external id of the transaction is reused with a quotation of another amount
+	second, err := tc.CreateQuotationForSource(ctx, pkg.DecimalFromInt(200), "KES", "USD", "1", "USA", pkg.C2C, "quotation-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.InjectFault(thunestest.Fault{Method: http.MethodPost, Path: "/v2/money-transfer/quotations/", StatusCode: http.StatusBadGateway, Times: 1})
+	transaction, err := tc.CreateTransaction(ctx, &transactionReq, &second.ID, nil)
+	if !errors.Is(err, api.ErrDuplicateExternalID) {
+		t.Fatalf("CreateTransaction() = %+v, %v, want ErrDuplicateExternalID", transaction, err)
+	}
+}
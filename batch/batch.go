@@ -0,0 +1,296 @@
+// Package batch performs bulk payouts read from a CSV or JSONL file.
+//
+// Each row is validated, then sent with api.ThunesClient.SendMoney, which quotes, creates and confirms
+// its transaction, by a bounded pool of workers. The result of every row is appended to a result file
+// as soon as it is known:
+//
+//	runner := batch.NewRunner(client, batch.WithConcurrency(8), batch.WithRateLimit(5))
+//	summary, err := runner.RunFile(ctx, "payouts.csv", "payouts.results.csv")
+//
+// The external id of a row identifies its quotation and transaction, so that running a batch again is safe:
+// the payouts confirmed according to the result file are skipped, and the others are resumed by SendMoney
+// from the quotation or transaction an interrupted run created, never sent twice.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+// DefaultConcurrency is the number of payouts performed at the same time.
+const DefaultConcurrency = 4
+
+// Sender performs payouts, *api.ThunesClient implements it.
+type Sender interface {
+	SendMoney(ctx context.Context, req *api.PayoutRequest) (*api.PayoutResult, error)
+}
+
+// Summary counts the outcomes of the payouts of a batch.
+type Summary struct {
+	Total     int
+	Confirmed int
+	Failed    int
+	Invalid   int
+	// Skipped counts the payouts confirmed by an earlier run.
+	Skipped int
+}
+
+// Runner performs batches of payouts.
+type Runner struct {
+	sender      Sender
+	concurrency int
+	interval    time.Duration
+	requote     *api.RequotePolicy
+	skipBalance bool
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithConcurrency sets the number of payouts performed at the same time, it defaults to DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.concurrency = n
+		}
+	}
+}
+
+// WithRateLimit starts at most perSecond payouts per second, whatever the concurrency.
+func WithRateLimit(perSecond float64) Option {
+	return func(r *Runner) {
+		if perSecond > 0 {
+			r.interval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// WithRequote re-creates the quotations which expire before their transaction is created, see api.RequotePolicy.
+func WithRequote(policy api.RequotePolicy) Option {
+	return func(r *Runner) {
+		r.requote = &policy
+	}
+}
+
+// WithoutBalanceCheck skips checking the balance before each transaction is created,
+// e.g. when the account is known to be funded for the whole batch.
+func WithoutBalanceCheck() Option {
+	return func(r *Runner) {
+		r.skipBalance = true
+	}
+}
+
+// NewRunner constructs a runner performing the payouts with sender.
+func NewRunner(sender Sender, opts ...Option) *Runner {
+	r := &Runner{
+		sender:      sender,
+		concurrency: DefaultConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RunFile performs the payouts of the input file and appends their results to the output file.
+// If the output file exists, it is the result file of an earlier run of the batch,
+// whose confirmed payouts are skipped.
+func (r *Runner) RunFile(ctx context.Context, input, output string) (*Summary, error) {
+	payouts, err := ReadFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := ReadResults(output)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool)
+	for id, result := range previous {
+		if result.Status == StatusConfirmed {
+			done[id] = true
+		}
+	}
+
+	w, err := CreateResultFile(output)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := r.Run(ctx, payouts, done, w)
+	if closeErr := w.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	return summary, err
+}
+
+// Run performs the payouts, skipping the ones whose external id is in done, and writes their results to w.
+// Rows are validated before anything is sent, and an external id used by several rows is only sent once.
+// It stops starting payouts when ctx is done or w fails, finishes the ones in progress and returns the error;
+// the payouts not started have no result.
+func (r *Runner) Run(ctx context.Context, payouts []Payout, done map[string]bool, w ResultWriter) (*Summary, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summary := &Summary{Total: len(payouts)}
+	var (
+		mu       sync.Mutex
+		writeErr error
+	)
+	record := func(result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch result.Status {
+		case StatusConfirmed:
+			summary.Confirmed++
+		case StatusFailed:
+			summary.Failed++
+		case StatusInvalid:
+			summary.Invalid++
+		}
+		if err := w.Write(result); err != nil && writeErr == nil {
+			writeErr = fmt.Errorf("batch: writing result of line %d: %w", result.Line, err)
+			cancel()
+		}
+	}
+
+	// validate every row first, so that a bad file is reported before anything is sent
+	seen := make(map[string]int)
+	var valid []*Payout
+	for i := range payouts {
+		p := &payouts[i]
+		if p.ExternalID != "" && done[p.ExternalID] {
+			summary.Skipped++
+			continue
+		}
+
+		err := p.Validate()
+		if line, ok := seen[p.ExternalID]; ok && err == nil {
+			err = fmt.Errorf("external_id %s is already used on line %d", p.ExternalID, line)
+		}
+		if err != nil {
+			record(Result{Line: p.Line, ExternalID: p.ExternalID, Status: StatusInvalid, Step: api.StepValidation, Error: err.Error()})
+			continue
+		}
+		seen[p.ExternalID] = p.Line
+		valid = append(valid, p)
+	}
+
+	var tick <-chan time.Time
+	if r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	jobs := make(chan *Payout)
+	var wg sync.WaitGroup
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				record(r.send(detached{ctx}, p))
+			}
+		}()
+	}
+
+dispatch:
+	for i, p := range valid {
+		// the first payout starts right away, the rate limit spaces out the next ones
+		if tick != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-tick:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- p:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if writeErr != nil {
+		return summary, writeErr
+	}
+	return summary, ctx.Err()
+}
+
+// detached keeps the values of a context but not its cancellation, so that a payout in progress is finished
+// when the batch is stopped: interrupted half way, its transaction would be cancelled and its external id burnt.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
+
+// send performs a payout and returns its result.
+func (r *Runner) send(ctx context.Context, p *Payout) Result {
+	mode := p.Mode
+	if mode == "" {
+		mode = pkg.SourceAmount
+	}
+
+	req := &api.PayoutRequest{
+		ExternalID:           p.ExternalID,
+		PayerID:              p.PayerID,
+		TransactionType:      p.TransactionType,
+		SourceCurrency:       p.SourceCurrency,
+		SourceCountryISOCode: p.SourceCountryISOCode,
+		DestinationCurrency:  p.DestinationCurrency,
+		Mode:                 mode,
+		Amount:               p.Amount.Unquoted(),
+		Transaction:          p.Transaction,
+		SkipBalanceCheck:     r.skipBalance,
+		Requote:              r.requote,
+	}
+
+	payout, err := r.sender.SendMoney(ctx, req)
+	result := Result{Line: p.Line, ExternalID: p.ExternalID, Status: StatusConfirmed}
+	if payout != nil {
+		if q := payout.Quotation; q != nil {
+			result.QuotationID = q.ID
+			result.SentAmount = q.SentAmount.String()
+			result.Destination = q.Destination.String()
+			result.Fee = q.Fee.String()
+		}
+		transaction := payout.Confirmed
+		if transaction == nil {
+			transaction = payout.Transaction
+		}
+		if transaction != nil {
+			if transaction.ID != nil {
+				result.TransactionID = *transaction.ID
+			}
+			if transaction.Status != nil {
+				result.TransactionStatus = *transaction.Status
+			}
+		}
+	}
+
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		var payoutErr *api.PayoutError
+		if errors.As(err, &payoutErr) {
+			result.Step = payoutErr.Step
+		}
+	}
+	return result
+}
@@ -0,0 +1,354 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"thunes-client/api"
+	"thunes-client/batch"
+	"thunes-client/thunestest"
+)
+
+// payoutLine returns a JSONL row paying amount USD to the M-Pesa payer of the default fixtures.
+func payoutLine(externalID, amount string) string {
+	return fmt.Sprintf(`{"external_id": %q, "payer_id": 1, "transaction_type": "C2C", "amount": %s, `+
+		`"source_currency": "USD", "source_country_iso_code": "USA", "destination_currency": "KES", `+
+		`"transaction": {"credit_party_identifier": {"msisdn": "254700000001"}, `+
+		`"sender": {"lastname": "Doe", "firstname": "John", "country_iso_code": "USA"}, `+
+		`"beneficiary": {"lastname": "Wanjiru", "firstname": "Jane"}}}`, externalID, amount)
+}
+
+func writeFile(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sent is a request received by the fake server, with its body.
+type sent struct {
+	method, path string
+	body         []byte
+}
+
+// recorder is a client middleware keeping the requests sent, so that their bodies can be checked.
+type recorder struct {
+	mu   sync.Mutex
+	sent []sent
+}
+
+func (rec *recorder) middleware(next api.Handler) api.Handler {
+	return api.HandlerFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.GetBody != nil {
+			if r, err := req.GetBody(); err == nil {
+				body, _ = ioutil.ReadAll(r)
+				r.Close()
+			}
+		}
+
+		rec.mu.Lock()
+		rec.sent = append(rec.sent, sent{method: req.Method, path: req.URL.Path, body: body})
+		rec.mu.Unlock()
+		return next.Do(req)
+	})
+}
+
+// created counts the transactions created for every external id.
+func (rec *recorder) created(t *testing.T) map[string]int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, s := range rec.sent {
+		if s.method != http.MethodPost || !strings.HasSuffix(s.path, "/transactions") {
+			continue
+		}
+		var body struct {
+			ExternalID string `json:"external_id"`
+		}
+		if err := json.Unmarshal(s.body, &body); err != nil {
+			t.Fatalf("transaction request %s: %v", s.body, err)
+		}
+		counts[body.ExternalID]++
+	}
+	return counts
+}
+
+// confirmed counts the confirmations of every transaction.
+func (rec *recorder) confirmed() map[string]int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, s := range rec.sent {
+		if s.method == http.MethodPost && strings.HasSuffix(s.path, "/confirm") {
+			counts[s.path]++
+		}
+	}
+	return counts
+}
+
+func newClient(t *testing.T) (*thunestest.Server, *api.ThunesClient, *recorder) {
+	t.Helper()
+	srv := thunestest.NewServer()
+	t.Cleanup(srv.Close)
+	rec := &recorder{}
+	return srv, srv.Client(api.WithRetryPolicy(api.NoRetry), api.WithMiddleware(rec.middleware)), rec
+}
+
+// interrupted stops a batch after a payout went through but before its result was known,
+// as a crash or a lost connection would. The payouts started after it fail without being sent.
+type interrupted struct {
+	batch.Sender
+	after  string
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	done bool
+}
+
+func (s *interrupted) SendMoney(ctx context.Context, req *api.PayoutRequest) (*api.PayoutResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return nil, errors.New("interrupted")
+	}
+	result, err := s.Sender.SendMoney(ctx, req)
+	if req.ExternalID == s.after {
+		s.done = true
+		s.cancel()
+		return result, errors.New("connection lost")
+	}
+	return result, err
+}
+
+func TestRunFileResumesInterruptedBatch(t *testing.T) {
+	_, tc, rec := newClient(t)
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "payouts.jsonl"), filepath.Join(dir, "payouts.results.jsonl")
+	writeFile(t, input, payoutLine("payout-1", `"100"`), payoutLine("payout-2", `"50"`), payoutLine("payout-3", "75"), payoutLine("payout-4", "20"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender := &interrupted{Sender: tc, after: "payout-2", cancel: cancel}
+	if _, err := batch.NewRunner(sender, batch.WithConcurrency(1)).RunFile(ctx, input, output); !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted RunFile() error = %v, want context.Canceled", err)
+	}
+
+	summary, err := batch.NewRunner(tc, batch.WithConcurrency(1)).RunFile(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("resumed RunFile() error = %v", err)
+	}
+	if summary.Skipped != 1 || summary.Confirmed != 3 {
+		t.Errorf("summary = %+v, want payout-1 skipped and the 3 others confirmed", summary)
+	}
+
+	results, err := batch.ReadResults(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := rec.created(t)
+	for _, id := range []string{"payout-1", "payout-2", "payout-3", "payout-4"} {
+		if results[id].Status != batch.StatusConfirmed {
+			t.Errorf("%s: last result %+v, want confirmed", id, results[id])
+		}
+		if created[id] != 1 {
+			t.Errorf("%s: %d transactions created, want 1", id, created[id])
+		}
+	}
+	if len(created) != 4 {
+		t.Errorf("transactions created for %v, want the 4 payouts", created)
+	}
+	for path, n := range rec.confirmed() {
+		if n != 1 {
+			t.Errorf("%s: %d confirmations, want 1", path, n)
+		}
+	}
+	if n := len(rec.confirmed()); n != 4 {
+		t.Errorf("%d transactions confirmed, want 4", n)
+	}
+}
+
+func TestRunFileRetriesFailedAndInvalidRows(t *testing.T) {
+	srv, tc, rec := newClient(t)
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "payouts.jsonl"), filepath.Join(dir, "payouts.results.jsonl")
+	writeFile(t, input, payoutLine("payout-1", "100"), payoutLine("payout-2", "0"))
+
+	// the quotation of payout-1 fails, payout-2 has no amount
+	srv.InjectFault(thunestest.Fault{Method: http.MethodPost, Path: "/v2/money-transfer/quotations", StatusCode: http.StatusInternalServerError, Times: 1})
+	summary, err := batch.NewRunner(tc, batch.WithConcurrency(1)).RunFile(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("RunFile() error = %v", err)
+	}
+	if summary.Failed != 1 || summary.Invalid != 1 {
+		t.Fatalf("summary = %+v, want 1 failed and 1 invalid", summary)
+	}
+	results, err := batch.ReadResults(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results["payout-1"]; r.Status != batch.StatusFailed || r.Step != api.StepQuotation {
+		t.Errorf("payout-1: %+v, want failed at the quotation step", r)
+	}
+	if r := results["payout-2"]; r.Status != batch.StatusInvalid || r.Line != 2 {
+		t.Errorf("payout-2: %+v, want invalid on line 2", r)
+	}
+
+	// the file is fixed and the batch run again
+	writeFile(t, input, payoutLine("payout-1", "100"), payoutLine("payout-2", "50"))
+	summary, err = batch.NewRunner(tc, batch.WithConcurrency(1)).RunFile(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("resumed RunFile() error = %v", err)
+	}
+	if summary.Confirmed != 2 || summary.Skipped != 0 {
+		t.Errorf("summary = %+v, want both payouts confirmed", summary)
+	}
+	if created := rec.created(t); created["payout-1"] != 1 || created["payout-2"] != 1 {
+		t.Errorf("transactions created %v, want 1 per payout", created)
+	}
+}
+
+func TestRunFileTruncatedLastLine(t *testing.T) {
+	_, tc, rec := newClient(t)
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "payouts.jsonl"), filepath.Join(dir, "payouts.results.jsonl")
+	truncated := payoutLine("payout-3", "75")
+	if err := ioutil.WriteFile(input, []byte(payoutLine("payout-1", "100")+"\n"+payoutLine("payout-2", "50")+"\n"+truncated[:60]), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := batch.NewRunner(tc).RunFile(context.Background(), input, output)
+	if err != nil {
+		t.Fatalf("RunFile() error = %v", err)
+	}
+	if summary.Total != 3 || summary.Confirmed != 2 || summary.Invalid != 1 {
+		t.Errorf("summary = %+v, want 2 confirmed and the truncated line invalid", summary)
+	}
+	if created := rec.created(t); len(created) != 2 {
+		t.Errorf("transactions created %v, want the 2 complete lines", created)
+	}
+
+	results, err := batch.ReadResults(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[""]; r.Status != batch.StatusInvalid || r.Line != 3 {
+		t.Errorf("truncated line: %+v, want invalid on line 3", r)
+	}
+}
+
+func TestRunDuplicateExternalIDs(t *testing.T) {
+	_, tc, rec := newClient(t)
+	payouts, err := batch.ReadJSONL(strings.NewReader(payoutLine("payout-1", "100") + "\n" + payoutLine("payout-1", "200") + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var w resultSlice
+	summary, err := batch.NewRunner(tc).Run(context.Background(), payouts, nil, &w)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if summary.Confirmed != 1 || summary.Invalid != 1 {
+		t.Errorf("summary = %+v, want 1 confirmed and 1 invalid", summary)
+	}
+	for _, r := range w.results {
+		if r.Line == 2 && (r.Status != batch.StatusInvalid || !strings.Contains(r.Error, "line 1")) {
+			t.Errorf("line 2: %+v, want invalid as a duplicate of line 1", r)
+		}
+	}
+	if created := rec.created(t); created["payout-1"] != 1 {
+		t.Errorf("%d transactions created for payout-1, want 1", created["payout-1"])
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "external_id,payer_id,transaction_type,amount,source_currency,source_country_iso_code,destination_currency," +
+		"credit_party_identifier.msisdn,sender.lastname,additional_information_1\n" +
+		"payout-1,1,C2C,100.50,USD,USA,KES,254700000001,Doe,\"first line\nsecond line\"\n" +
+		"payout-2,1,c2c,20,USD,USA,KES,254700000002,Roe,\n"
+
+	payouts, err := batch.ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payouts) != 2 {
+		t.Fatalf("%d payouts, want 2", len(payouts))
+	}
+
+	p := payouts[0]
+	if p.Line != 2 || p.ExternalID != "payout-1" || p.PayerID != 1 || p.Amount.String() != "100.50" {
+		t.Errorf("payout 1 = %+v", p)
+	}
+	if p.Transaction.CreditPartyIdentifier == nil || p.Transaction.CreditPartyIdentifier.MSISDN != "254700000001" {
+		t.Errorf("credit party = %+v, want the msisdn", p.Transaction.CreditPartyIdentifier)
+	}
+	if p.Transaction.AdditionalInformation1 == nil || *p.Transaction.AdditionalInformation1 != "first line\nsecond line" {
+		t.Errorf("additional information = %v, want the multi-line cell", p.Transaction.AdditionalInformation1)
+	}
+	// the quoted cell of payout-1 spans lines 2 and 3
+	if payouts[1].Line != 4 {
+		t.Errorf("payout 2 on line %d, want 4", payouts[1].Line)
+	}
+	if err := payouts[1].Validate(); err != nil {
+		t.Errorf("payout 2: %v", err)
+	}
+}
+
+func TestRunSendsAmountsAsNumbers(t *testing.T) {
+	_, tc, rec := newClient(t)
+	payouts, err := batch.ReadJSONL(strings.NewReader(payoutLine("payout-1", `"100"`) + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := batch.NewRunner(tc).Run(context.Background(), payouts, nil, &resultSlice{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	quotations := 0
+	for _, s := range rec.sent {
+		if s.method != http.MethodPost || s.path != "/v2/money-transfer/quotations" {
+			continue
+		}
+		quotations++
+		var body struct {
+			Source struct {
+				Amount json.RawMessage `json:"amount"`
+			} `json:"source"`
+		}
+		if err := json.Unmarshal(s.body, &body); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(body.Source.Amount, []byte("100")) {
+			t.Errorf("quotation amount %s, want the number 100", body.Source.Amount)
+		}
+	}
+	if quotations != 1 {
+		t.Errorf("%d quotations sent, want 1", quotations)
+	}
+}
+
+// resultSlice is a ResultWriter keeping the results in memory.
+type resultSlice struct {
+	results []batch.Result
+}
+
+func (w *resultSlice) Write(result batch.Result) error {
+	w.results = append(w.results, result)
+	return nil
+}
@@ -0,0 +1,239 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"thunes-client/pkg"
+)
+
+// Payout is a row of a payout file.
+//
+// In a JSONL file each line is a JSON object with the fields below, the transaction being
+// a pkg.CreateTransactionRequest object. In a CSV file the first row names the columns:
+// the fields below by their JSON name and the fields of the transaction by their JSON path,
+// e.g. "sender.lastname" or "credit_party_identifier.msisdn". Empty cells are left unset.
+type Payout struct {
+	// Line is the line the payout starts on in its file, starting at 1.
+	Line int `json:"-"`
+
+	ExternalID           string              `json:"external_id"`
+	PayerID              int                 `json:"payer_id"`
+	TransactionType      pkg.TransactionType `json:"transaction_type"`
+	Mode                 pkg.QuotationMode   `json:"mode"` // defaults to SOURCE_AMOUNT
+	Amount               pkg.Decimal         `json:"amount"`
+	SourceCurrency       string              `json:"source_currency"`
+	SourceCountryISOCode string              `json:"source_country_iso_code"`
+	DestinationCurrency  string              `json:"destination_currency"`
+
+	// Transaction holds the sender, beneficiary and credit party details, its external id is ignored.
+	Transaction pkg.CreateTransactionRequest `json:"transaction"`
+
+	// err is the error met while reading the row, if any
+	err error
+}
+
+// Validate checks the row can be sent, the transaction is checked against the requirements of the payer
+// when the payout is performed.
+func (p *Payout) Validate() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	verr := &pkg.ValidationError{}
+	add := func(field, message string) {
+		verr.Errors = append(verr.Errors, pkg.FieldError{Field: field, Message: message})
+	}
+
+	if p.ExternalID == "" {
+		add("external_id", "is mandatory")
+	}
+	if p.PayerID <= 0 {
+		add("payer_id", "is mandatory")
+	}
+	if err := p.TransactionType.Validate(); err != nil {
+		add("transaction_type", err.Error())
+	}
+	if p.Mode != "" {
+		if err := p.Mode.Validate(); err != nil {
+			add("mode", err.Error())
+		}
+	}
+	if p.Amount.Sign() <= 0 {
+		add("amount", "must be positive")
+	}
+	if p.SourceCurrency == "" {
+		add("source_currency", "is mandatory")
+	}
+	if p.SourceCountryISOCode == "" {
+		add("source_country_iso_code", "is mandatory")
+	}
+	if p.DestinationCurrency == "" {
+		add("destination_currency", "is mandatory")
+	}
+	if id := p.Transaction.CreditPartyIdentifier; id == nil {
+		add("credit_party_identifier", "is mandatory")
+	} else if err := id.Validate(); err != nil {
+		var idErr *pkg.ValidationError
+		if errors.As(err, &idErr) {
+			verr.Errors = append(verr.Errors, idErr.Errors...)
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// ReadFile reads the payouts of a CSV file, or of a JSONL file if its extension is not .csv.
+func ReadFile(path string) ([]Payout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ReadCSV(f)
+	}
+	return ReadJSONL(f)
+}
+
+// ReadJSONL reads payouts from one JSON object per line, blank lines are skipped.
+// A line which cannot be decoded is returned as a payout failing Validate.
+func ReadJSONL(r io.Reader) ([]Payout, error) {
+	var payouts []Payout
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		payouts = append(payouts, decodePayout(line, data))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return payouts, nil
+}
+
+// intColumns are the columns of a CSV file holding JSON numbers rather than strings.
+var intColumns = map[string]bool{
+	"payer_id":                             true,
+	"credit_party_identifier.entity_tt_id": true,
+}
+
+// topLevelColumns are the columns of a CSV file holding the fields of Payout rather than of its transaction.
+var topLevelColumns = map[string]bool{
+	"external_id":             true,
+	"payer_id":                true,
+	"transaction_type":        true,
+	"mode":                    true,
+	"amount":                  true,
+	"source_currency":         true,
+	"source_country_iso_code": true,
+	"destination_currency":    true,
+}
+
+// ReadCSV reads payouts from a CSV file whose first row names the columns.
+// A row which cannot be decoded is returned as a payout failing Validate.
+func ReadCSV(r io.Reader) ([]Payout, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("batch: reading CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var payouts []Payout
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// a csv.ParseError carries the line of the error
+			return nil, fmt.Errorf("batch: reading CSV: %w", err)
+		}
+		// the line the record starts on, a quoted cell may span several lines
+		line, _ := reader.FieldPos(0)
+
+		// rebuild the JSON object of the row from the paths of the columns
+		row := make(map[string]interface{})
+		transaction := make(map[string]interface{})
+		for i, cell := range record {
+			if i >= len(header) || header[i] == "" || strings.TrimSpace(cell) == "" {
+				continue
+			}
+			value := interface{}(strings.TrimSpace(cell))
+			if intColumns[header[i]] {
+				if n, err := strconv.Atoi(strings.TrimSpace(cell)); err == nil {
+					value = n
+				}
+			}
+
+			if topLevelColumns[header[i]] {
+				row[header[i]] = value
+			} else {
+				setPath(transaction, strings.Split(header[i], "."), value)
+			}
+		}
+		row["transaction"] = transaction
+
+		data, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, decodePayout(line, data))
+	}
+
+	return payouts, nil
+}
+
+// setPath sets the value at the path of nested objects, creating them as needed.
+func setPath(object map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+		object = child
+	}
+	object[path[len(path)-1]] = value
+}
+
+// decodePayout decodes the JSON object of a row. The external id is kept when the rest cannot be decoded,
+// so that the error can be reported for it.
+func decodePayout(line int, data []byte) Payout {
+	payout := Payout{Line: line}
+	if err := json.Unmarshal(data, &payout); err != nil {
+		var id struct {
+			ExternalID string `json:"external_id"`
+		}
+		_ = json.Unmarshal(data, &id)
+
+		return Payout{Line: line, ExternalID: id.ExternalID, err: fmt.Errorf("batch: line %d: %w", line, err)}
+	}
+	return payout
+}
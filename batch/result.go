@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"thunes-client/pkg"
+)
+
+// Status is the outcome of a payout of a batch.
+type Status string
+
+const (
+	// StatusConfirmed means the transaction of the payout was confirmed, it will not be sent again.
+	StatusConfirmed = Status("confirmed")
+	// StatusFailed means the payout failed, it is sent again when the batch is resumed.
+	StatusFailed = Status("failed")
+	// StatusInvalid means the row could not be sent as is, it is checked again when the batch is resumed.
+	StatusInvalid = Status("invalid")
+)
+
+// Result is the outcome of a payout, written to the result file.
+type Result struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id"`
+	Status     Status `json:"status"`
+	// Step is the step of the payout which failed, see api.PayoutError.
+	Step  string `json:"step,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	QuotationID       int                   `json:"quotation_id,omitempty"`
+	TransactionID     int                   `json:"transaction_id,omitempty"`
+	TransactionStatus pkg.TransactionStatus `json:"transaction_status,omitempty"`
+	SentAmount        string                `json:"sent_amount,omitempty"`
+	Destination       string                `json:"destination,omitempty"`
+	Fee               string                `json:"fee,omitempty"`
+}
+
+// resultColumns are the columns of a CSV result file.
+var resultColumns = []string{
+	"line", "external_id", "status", "step", "error",
+	"quotation_id", "transaction_id", "transaction_status", "sent_amount", "destination", "fee",
+}
+
+func (r *Result) record() []string {
+	itoa := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	return []string{
+		strconv.Itoa(r.Line), r.ExternalID, string(r.Status), r.Step, r.Error,
+		itoa(r.QuotationID), itoa(r.TransactionID), string(r.TransactionStatus), r.SentAmount, r.Destination, r.Fee,
+	}
+}
+
+func parseRecord(header, record []string) Result {
+	var r Result
+	for i, cell := range record {
+		if i >= len(header) {
+			break
+		}
+		switch header[i] {
+		case "line":
+			r.Line, _ = strconv.Atoi(cell)
+		case "external_id":
+			r.ExternalID = cell
+		case "status":
+			r.Status = Status(cell)
+		case "step":
+			r.Step = cell
+		case "error":
+			r.Error = cell
+		case "quotation_id":
+			r.QuotationID, _ = strconv.Atoi(cell)
+		case "transaction_id":
+			r.TransactionID, _ = strconv.Atoi(cell)
+		case "transaction_status":
+			r.TransactionStatus = pkg.TransactionStatus(cell)
+		case "sent_amount":
+			r.SentAmount = cell
+		case "destination":
+			r.Destination = cell
+		case "fee":
+			r.Fee = cell
+		}
+	}
+	return r
+}
+
+// ResultWriter records the results of a batch, it is called by one payout at a time.
+type ResultWriter interface {
+	Write(result Result) error
+}
+
+// FileWriter appends the results to a CSV file, or to a JSONL file if its extension is not .csv.
+// Every result is flushed as soon as it is written, so that an interrupted batch can be resumed from the file.
+type FileWriter struct {
+	mu  sync.Mutex
+	f   *os.File
+	csv *csv.Writer
+}
+
+// CreateResultFile opens the result file at path for appending, creating it if needed.
+func CreateResultFile(path string) (*FileWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &FileWriter{f: f}
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		return w, nil
+	}
+
+	w.csv = csv.NewWriter(f)
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		if err := w.csv.Write(resultColumns); err != nil {
+			f.Close()
+			return nil, err
+		}
+		w.csv.Flush()
+	}
+
+	return w, nil
+}
+
+func (w *FileWriter) Write(result Result) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.csv != nil {
+		if err := w.csv.Write(result.record()); err != nil {
+			return err
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = w.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.f.Close()
+}
+
+// ReadResults reads the results recorded in a result file, by external id. When a payout was recorded
+// several times, e.g. failed then confirmed when the batch was resumed, its last result is kept.
+// A missing file has no results.
+func ReadResults(path string) (map[string]Result, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]Result{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	results := make(map[string]Result)
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		reader := csv.NewReader(f)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("batch: reading results: %w", err)
+		}
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("batch: reading results: %w", err)
+			}
+			result := parseRecord(header, record)
+			results[result.ExternalID] = result
+		}
+		return results, nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var result Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			// the last line may have been cut by an interruption, its payout is sent again
+			continue
+		}
+		results[result.ExternalID] = result
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("batch: reading results: %w", err)
+	}
+	return results, nil
+}
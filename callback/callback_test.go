@@ -0,0 +1,320 @@
+package callback_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"thunes-client/callback"
+	"thunes-client/pkg"
+)
+
+const completed = `{"id": 42, "status": "70000", "status_class": "7", "external_id": "payout-1"}`
+
+// post sends a callback to h as Thunes does, httptest sets its remote address to 192.0.2.1.
+func post(h http.Handler, body string, edit func(r *http.Request)) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/thunes/callback", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if edit != nil {
+		edit(r)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func newHandler(t *testing.T, opts ...callback.Option) *callback.Handler {
+	t.Helper()
+	h, err := callback.NewHandler(opts...)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	return h
+}
+
+func TestNewHandlerRequiresAuthentication(t *testing.T) {
+	if _, err := callback.NewHandler(); !errors.Is(err, callback.ErrNoAuthentication) {
+		t.Errorf("NewHandler() error = %v, want ErrNoAuthentication", err)
+	}
+	if _, err := callback.NewHandler(callback.WithAllowedIPs("192.0.2.0/24")); !errors.Is(err, callback.ErrNoAuthentication) {
+		t.Errorf("NewHandler(WithAllowedIPs) error = %v, want ErrNoAuthentication", err)
+	}
+	if _, err := callback.NewHandler(callback.WithSharedSecret("X-Token", "")); err == nil {
+		t.Error("NewHandler() with an empty shared secret succeeded")
+	}
+	if _, err := callback.NewHandler(callback.WithAllowedIPs("not an ip"), callback.WithoutAuthentication()); err == nil {
+		t.Error("NewHandler() with an invalid allowed IP succeeded")
+	}
+
+	h := newHandler(t, callback.WithoutAuthentication())
+	if w := post(h, completed, nil); w.Code != http.StatusOK {
+		t.Errorf("unauthenticated callback answered %d, want 200", w.Code)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(r *http.Request)
+		want int
+	}{
+		{name: "valid", edit: func(r *http.Request) { r.SetBasicAuth("thunes", "s3cret") }, want: http.StatusOK},
+		{name: "missing", want: http.StatusUnauthorized},
+		{name: "wrong password", edit: func(r *http.Request) { r.SetBasicAuth("thunes", "guess") }, want: http.StatusUnauthorized},
+		{name: "wrong user", edit: func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(t, callback.WithBasicAuth("thunes", "s3cret"))
+			called := false
+			h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+				called = true
+				return nil
+			})
+
+			w := post(h, completed, tt.edit)
+			if w.Code != tt.want {
+				t.Errorf("answered %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized {
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("no WWW-Authenticate header")
+				}
+				if called {
+					t.Error("an unauthenticated callback was dispatched")
+				}
+			}
+		})
+	}
+}
+
+func TestSharedSecret(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(r *http.Request)
+		want int
+	}{
+		{name: "header", edit: func(r *http.Request) { r.Header.Set("X-Token", "s3cret") }, want: http.StatusOK},
+		{name: "query", edit: func(r *http.Request) { r.URL.RawQuery = "X-Token=s3cret" }, want: http.StatusOK},
+		{name: "missing", want: http.StatusUnauthorized},
+		{name: "wrong", edit: func(r *http.Request) { r.Header.Set("X-Token", "guess") }, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(t, callback.WithSharedSecret("X-Token", "s3cret"))
+			if w := post(h, completed, tt.edit); w.Code != tt.want {
+				t.Errorf("answered %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedIPs(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []callback.Option
+		remote  string
+		forward string
+		want    int
+	}{
+		{name: "in range", opts: []callback.Option{callback.WithAllowedIPs("192.0.2.0/24")}, remote: "192.0.2.1:1234", want: http.StatusOK},
+		{name: "single address", opts: []callback.Option{callback.WithAllowedIPs("192.0.2.1")}, remote: "192.0.2.1:1234", want: http.StatusOK},
+		{name: "out of range", opts: []callback.Option{callback.WithAllowedIPs("192.0.2.0/24")}, remote: "198.51.100.7:1234", want: http.StatusForbidden},
+		{
+			name:    "forwarded for ignored",
+			opts:    []callback.Option{callback.WithAllowedIPs("192.0.2.0/24")},
+			remote:  "198.51.100.7:1234",
+			forward: "192.0.2.1",
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "forwarded for trusted",
+			opts:    []callback.Option{callback.WithAllowedIPs("192.0.2.0/24"), callback.WithTrustForwardedFor()},
+			remote:  "10.0.0.1:1234",
+			forward: "192.0.2.1",
+			want:    http.StatusOK,
+		},
+		{
+			// only the hop appended by the proxy can be trusted, the client sets the ones before it
+			name:    "forwarded for spoofed",
+			opts:    []callback.Option{callback.WithAllowedIPs("192.0.2.0/24"), callback.WithTrustForwardedFor()},
+			remote:  "10.0.0.1:1234",
+			forward: "192.0.2.1, 198.51.100.7",
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "forwarded for invalid",
+			opts:    []callback.Option{callback.WithAllowedIPs("192.0.2.0/24"), callback.WithTrustForwardedFor()},
+			remote:  "192.0.2.1:1234",
+			forward: "unknown",
+			want:    http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(t, append(tt.opts, callback.WithSharedSecret("X-Token", "s3cret"))...)
+			w := post(h, completed, func(r *http.Request) {
+				r.RemoteAddr = tt.remote
+				r.Header.Set("X-Token", "s3cret")
+				if tt.forward != "" {
+					r.Header.Set("X-Forwarded-For", tt.forward)
+				}
+			})
+			if w.Code != tt.want {
+				t.Errorf("answered %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidCallbacks(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{name: "method", method: http.MethodGet, body: completed, want: http.StatusMethodNotAllowed},
+		{name: "oversize", body: `{"id": 42, "status": "70000", "padding": "` + strings.Repeat("x", 1<<20) + `"}`, want: http.StatusRequestEntityTooLarge},
+		{name: "malformed", body: `{"id": 42, "status": `, want: http.StatusBadRequest},
+		{name: "not an object", body: `[1, 2]`, want: http.StatusBadRequest},
+		{name: "no id", body: `{"status": "70000"}`, want: http.StatusBadRequest},
+		{name: "no status", body: `{"id": 42}`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(t, callback.WithoutAuthentication())
+			h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+				t.Error("an invalid callback was dispatched")
+				return nil
+			})
+
+			w := post(h, tt.body, func(r *http.Request) {
+				if tt.method != "" {
+					r.Method = tt.method
+				}
+			})
+			if w.Code != tt.want {
+				t.Errorf("answered %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchByStatusClass(t *testing.T) {
+	h := newHandler(t, callback.WithoutAuthentication())
+	var got []string
+	h.Handle(pkg.StatusClassCompleted, func(ctx context.Context, tx *pkg.Transaction) error {
+		got = append(got, "completed "+*tx.ExternalID)
+		return nil
+	})
+	h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+		got = append(got, "default "+string(*tx.Status))
+		return nil
+	})
+
+	post(h, completed, nil)
+	post(h, `{"id": 42, "status": "20000"}`, nil)
+
+	if len(got) != 2 || got[0] != "completed payout-1" || got[1] != "default 20000" {
+		t.Errorf("dispatched %q, want the completed then the default handler", got)
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	h := newHandler(t, callback.WithoutAuthentication())
+	calls := 0
+	h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := post(h, completed, nil); w.Code != http.StatusOK {
+			t.Errorf("delivery %d answered %d, want 200", i+1, w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("processed %d times, want 1", calls)
+	}
+
+	// another status of the same transaction is not a redelivery
+	post(h, `{"id": 42, "status": "80000"}`, nil)
+	if calls != 2 {
+		t.Errorf("processed %d times, want 2", calls)
+	}
+}
+
+func TestRedeliveryAfterFailure(t *testing.T) {
+	h := newHandler(t, callback.WithoutAuthentication())
+	calls := 0
+	h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+		calls++
+		if calls == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	if w := post(h, completed, nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failed delivery answered %d, want 500", w.Code)
+	}
+	if w := post(h, completed, nil); w.Code != http.StatusOK {
+		t.Fatalf("redelivery answered %d, want 200", w.Code)
+	}
+	if calls != 2 {
+		t.Errorf("processed %d times, want 2", calls)
+	}
+}
+
+func TestRedeliveryInFlight(t *testing.T) {
+	h := newHandler(t, callback.WithoutAuthentication())
+	started, fail := make(chan struct{}), make(chan error)
+	calls := 0
+	h.HandleDefault(func(ctx context.Context, tx *pkg.Transaction) error {
+		calls++
+		if calls == 1 {
+			close(started)
+			return <-fail
+		}
+		return nil
+	})
+
+	var (
+		wg    sync.WaitGroup
+		first *httptest.ResponseRecorder
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = post(h, completed, nil)
+	}()
+	<-started
+
+	// the first delivery may still fail, the redelivery must not be acknowledged yet
+	if w := post(h, completed, nil); w.Code != http.StatusConflict {
+		t.Errorf("redelivery during processing answered %d, want 409", w.Code)
+	}
+
+	fail <- errors.New("database unavailable")
+	wg.Wait()
+	if first.Code != http.StatusInternalServerError {
+		t.Errorf("failed delivery answered %d, want 500", first.Code)
+	}
+
+	// the status is delivered again and processed this time
+	if w := post(h, completed, nil); w.Code != http.StatusOK {
+		t.Errorf("redelivery after the failure answered %d, want 200", w.Code)
+	}
+	if calls != 2 {
+		t.Errorf("processed %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,96 @@
+package callback
+
+import (
+	"sync"
+	"time"
+)
+
+// ClaimResult is the state of a callback key reported by Deduplicator.Claim.
+type ClaimResult int
+
+const (
+	// Claimed means the key was new, the callback must be processed and the key completed or released.
+	Claimed ClaimResult = iota
+	// InFlight means the callback is being processed, its outcome is not known yet.
+	InFlight
+	// Processed means the callback was processed successfully.
+	Processed
+)
+
+// Deduplicator remembers the callbacks being processed and the ones already processed.
+type Deduplicator interface {
+	// Claim records the key as in flight if it is new, and returns Claimed, or returns its current state.
+	// It must be atomic, so that concurrent redeliveries of a callback are processed only once.
+	Claim(key string) ClaimResult
+	// Complete records that the callback of a claimed key was processed, so that its redeliveries are acknowledged.
+	Complete(key string)
+	// Release forgets the key, so that a redelivery of a callback which failed is processed again.
+	Release(key string)
+}
+
+// MemoryDeduplicator is an in-memory Deduplicator forgetting keys after a time window.
+type MemoryDeduplicator struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	seen   map[string]entry
+	claims int
+}
+
+type entry struct {
+	expiry time.Time
+	done   bool
+}
+
+// sweepEvery is the number of claims between two removals of the expired keys.
+const sweepEvery = 1024
+
+// NewMemoryDeduplicator constructs a deduplicator remembering keys for the given window.
+// A key in flight for longer than the window, e.g. because its processing hung, is claimed again.
+func NewMemoryDeduplicator(window time.Duration) *MemoryDeduplicator {
+	return &MemoryDeduplicator{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[string]entry),
+	}
+}
+
+func (d *MemoryDeduplicator) Claim(key string) ClaimResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.claims++
+	if d.claims%sweepEvery == 0 {
+		for k, e := range d.seen {
+			if !now.Before(e.expiry) {
+				delete(d.seen, k)
+			}
+		}
+	}
+
+	if e, ok := d.seen[key]; ok && now.Before(e.expiry) {
+		if e.done {
+			return Processed
+		}
+		return InFlight
+	}
+
+	d.seen[key] = entry{expiry: now.Add(d.window)}
+	return Claimed
+}
+
+func (d *MemoryDeduplicator) Complete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seen[key] = entry{expiry: d.now().Add(d.window), done: true}
+}
+
+func (d *MemoryDeduplicator) Release(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.seen, key)
+}
@@ -0,0 +1,349 @@
+// Package catalog caches the services, countries and payers available to a Thunes account,
+// so that they can be looked up on hot paths without a round trip to the API.
+//
+// A Catalog loads the whole catalog through the paginated list endpoints, serves it from memory
+// and loads it again once it is older than its TTL. Run refreshes it in the background, before
+// it expires, so that lookups never wait for the network:
+//
+//	cat := catalog.New(client, catalog.WithTTL(30*time.Minute), catalog.WithSnapshot("/var/cache/thunes/catalog.json"))
+//	if err := cat.Load(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	go cat.Run(ctx)
+//
+//	payers, err := cat.FindPayers(ctx, catalog.Filter{CountryISOCode: "PHL", TransactionType: pkg.C2C})
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+// DefaultTTL is how long a loaded catalog is served before it is loaded again.
+const DefaultTTL = time.Hour
+
+// ErrNotFound is returned by the lookups of a single item which is not in the catalog.
+var ErrNotFound = errors.New("catalog: not found")
+
+// Source loads the catalog, *api.ThunesClient implements it.
+type Source interface {
+	AllServices(ctx context.Context, countryISOCode *string, opts ...api.PageOption) ([]pkg.Service, error)
+	AllCountries(ctx context.Context, opts ...api.PageOption) ([]pkg.Country, error)
+	AllPayers(ctx context.Context, serviceID *int, countryISOCode, currency *string, opts ...api.PageOption) ([]pkg.Payer, error)
+}
+
+// Catalog is an in-memory cache of the Thunes catalog, safe for concurrent use.
+// The slices and payers it returns are shared and must not be modified.
+type Catalog struct {
+	source          Source
+	ttl             time.Duration
+	refreshInterval time.Duration
+	snapshotPath    string
+	onError         func(error)
+	now             func() time.Time
+
+	// refreshMu serializes the refreshes, so that concurrent lookups of an expired catalog load it once
+	refreshMu sync.Mutex
+
+	mu   sync.RWMutex
+	data *snapshot
+}
+
+// Option configures a Catalog.
+type Option func(*Catalog)
+
+// WithTTL sets how long a loaded catalog is served before it is loaded again, it defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Catalog) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// WithRefreshInterval sets how often Run refreshes the catalog, it defaults to half the TTL.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(c *Catalog) {
+		if interval > 0 {
+			c.refreshInterval = interval
+		}
+	}
+}
+
+// WithSnapshot saves the catalog to the file at path after every refresh, and has Load start from it,
+// so that a process can serve lookups before its first refresh completes.
+func WithSnapshot(path string) Option {
+	return func(c *Catalog) {
+		c.snapshotPath = path
+	}
+}
+
+// WithErrorHandler has fn called with the errors of the background refreshes and of the snapshot writes,
+// which are otherwise ignored: the previous catalog keeps being served.
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *Catalog) {
+		c.onError = fn
+	}
+}
+
+// New constructs a catalog loading its content from source. Nothing is loaded until Load or the first lookup.
+func New(source Source, opts ...Option) *Catalog {
+	c := &Catalog{
+		source:  source,
+		ttl:     DefaultTTL,
+		onError: func(error) {},
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.refreshInterval == 0 {
+		c.refreshInterval = c.ttl / 2
+	}
+
+	return c
+}
+
+// Load prepares the catalog for lookups. It starts from the snapshot file if one is configured,
+// and loads the catalog from the source unless the snapshot is still fresh. A stale snapshot
+// is kept if the source cannot be reached, the error is only returned when there is no catalog at all.
+func (c *Catalog) Load(ctx context.Context) error {
+	if c.snapshotPath != "" {
+		data, err := readSnapshot(c.snapshotPath)
+		switch {
+		case err == nil:
+			c.set(data)
+			if c.fresh(data) {
+				return nil
+			}
+		case !errors.Is(err, errNoSnapshot):
+			c.onError(err)
+		}
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		if c.get() != nil {
+			c.onError(err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Refresh loads the whole catalog from the source and replaces the one in memory.
+func (c *Catalog) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	return c.refresh(ctx)
+}
+
+func (c *Catalog) refresh(ctx context.Context) error {
+	services, err := c.source.AllServices(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("catalog: loading services: %w", err)
+	}
+	countries, err := c.source.AllCountries(ctx)
+	if err != nil {
+		return fmt.Errorf("catalog: loading countries: %w", err)
+	}
+	payers, err := c.source.AllPayers(ctx, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("catalog: loading payers: %w", err)
+	}
+
+	data := newSnapshot(c.now(), services, countries, payers)
+	c.set(data)
+
+	if c.snapshotPath != "" {
+		if err := writeSnapshot(c.snapshotPath, data); err != nil {
+			c.onError(err)
+		}
+	}
+	return nil
+}
+
+// Run refreshes the catalog every refresh interval until ctx is done, and returns ctx.Err().
+// The errors are passed to the error handler and the refresh is tried again at the next interval.
+func (c *Catalog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+				c.onError(err)
+			}
+		}
+	}
+}
+
+// LoadedAt returns when the catalog served was loaded from the source, the zero time if it was not loaded yet.
+func (c *Catalog) LoadedAt() time.Time {
+	if data := c.get(); data != nil {
+		return data.LoadedAt
+	}
+	return time.Time{}
+}
+
+func (c *Catalog) get() *snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.data
+}
+
+func (c *Catalog) set(data *snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data = data
+}
+
+func (c *Catalog) fresh(data *snapshot) bool {
+	return c.now().Sub(data.LoadedAt) < c.ttl
+}
+
+// current returns the catalog, loading it first if it is missing or expired. An expired catalog is
+// still returned when it cannot be loaded again, the error is passed to the error handler.
+func (c *Catalog) current(ctx context.Context) (*snapshot, error) {
+	if data := c.get(); data != nil && c.fresh(data) {
+		return data, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// another lookup may have refreshed it while this one was waiting
+	if data := c.get(); data != nil && c.fresh(data) {
+		return data, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		if data := c.get(); data != nil {
+			c.onError(err)
+			return data, nil
+		}
+		return nil, err
+	}
+	return c.get(), nil
+}
+
+// Services returns every service.
+func (c *Catalog) Services(ctx context.Context) ([]pkg.Service, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.Services, nil
+}
+
+// Countries returns every country with a money transfer service.
+func (c *Catalog) Countries(ctx context.Context) ([]pkg.Country, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.Countries, nil
+}
+
+// Country returns the country with the given ISO 3166-1 alpha-3 code.
+func (c *Catalog) Country(ctx context.Context, isoCode string) (*pkg.Country, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data.Countries {
+		if strings.EqualFold(data.Countries[i].ISOCode, isoCode) {
+			return &data.Countries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: country %s", ErrNotFound, isoCode)
+}
+
+// Payers returns every payer.
+func (c *Catalog) Payers(ctx context.Context) ([]pkg.Payer, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.Payers, nil
+}
+
+// Payer returns the payer with the given id.
+func (c *Catalog) Payer(ctx context.Context, id int) (*pkg.Payer, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := data.payersByID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: payer %d", ErrNotFound, id)
+	}
+	return &data.Payers[i], nil
+}
+
+// Filter selects payers, its zero fields match every payer.
+// A payer which does not publish its transaction types matches every transaction type.
+type Filter struct {
+	CountryISOCode  string
+	Currency        string
+	ServiceID       int
+	TransactionType pkg.TransactionType
+}
+
+func (f Filter) match(payer *pkg.Payer) bool {
+	if f.CountryISOCode != "" && !strings.EqualFold(payer.CountryISOCode, f.CountryISOCode) {
+		return false
+	}
+	if f.Currency != "" && !strings.EqualFold(payer.Currency, f.Currency) {
+		return false
+	}
+	if f.ServiceID != 0 && payer.Service.ID != f.ServiceID {
+		return false
+	}
+	if f.TransactionType != "" && len(payer.TransactionTypes) > 0 {
+		if _, ok := payer.TransactionTypes[f.TransactionType]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// FindPayers returns the payers matching the filter, in the order of the catalog.
+func (c *Catalog) FindPayers(ctx context.Context, filter Filter) ([]pkg.Payer, error) {
+	data, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var payers []pkg.Payer
+	if filter.CountryISOCode != "" {
+		for _, i := range data.payersByCountry[strings.ToUpper(filter.CountryISOCode)] {
+			if filter.match(&data.Payers[i]) {
+				payers = append(payers, data.Payers[i])
+			}
+		}
+		return payers, nil
+	}
+
+	for i := range data.Payers {
+		if filter.match(&data.Payers[i]) {
+			payers = append(payers, data.Payers[i])
+		}
+	}
+	return payers, nil
+}
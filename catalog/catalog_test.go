@@ -0,0 +1,258 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+// fakeSource serves the default fixtures of thunestest and counts the loads of the catalog.
+type fakeSource struct {
+	mu    sync.Mutex
+	loads int
+	err   error
+}
+
+func (s *fakeSource) AllServices(ctx context.Context, countryISOCode *string, opts ...api.PageOption) ([]pkg.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.loads++
+	return thunestest.DefaultFixtures().Services, nil
+}
+
+func (s *fakeSource) AllCountries(ctx context.Context, opts ...api.PageOption) ([]pkg.Country, error) {
+	return thunestest.DefaultFixtures().Countries, nil
+}
+
+func (s *fakeSource) AllPayers(ctx context.Context, serviceID *int, countryISOCode, currency *string, opts ...api.PageOption) ([]pkg.Payer, error) {
+	return thunestest.DefaultFixtures().Payers, nil
+}
+
+func (s *fakeSource) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loads
+}
+
+func (s *fakeSource) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// clock is a time which the tests move forward.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newCatalog(source Source, opts ...Option) (*Catalog, *clock) {
+	c := New(source, opts...)
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.now = clk.Now
+	return c, clk
+}
+
+func TestLookups(t *testing.T) {
+	source := &fakeSource{}
+	c, _ := newCatalog(source)
+	ctx := context.Background()
+
+	payer, err := c.Payer(ctx, 2)
+	if err != nil || payer.Name != "BDO Philippines" {
+		t.Fatalf("Payer(2) = %+v, %v", payer, err)
+	}
+	if _, err := c.Payer(ctx, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Payer(42) error = %v, want ErrNotFound", err)
+	}
+	if country, err := c.Country(ctx, "ken"); err != nil || country.Name != "Kenya" {
+		t.Errorf("Country(ken) = %+v, %v", country, err)
+	}
+
+	tests := []struct {
+		filter Filter
+		want   []int
+	}{
+		{filter: Filter{}, want: []int{1, 2}},
+		{filter: Filter{CountryISOCode: "phl"}, want: []int{2}},
+		{filter: Filter{Currency: "KES"}, want: []int{1}},
+		{filter: Filter{ServiceID: 2}, want: []int{2}},
+		{filter: Filter{TransactionType: pkg.B2B}, want: []int{2}},
+		{filter: Filter{CountryISOCode: "KEN", TransactionType: pkg.B2B}, want: nil},
+	}
+	for _, tt := range tests {
+		payers, err := c.FindPayers(ctx, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, p := range payers {
+			ids = append(ids, p.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("FindPayers(%+v) = %v, want %v", tt.filter, ids, tt.want)
+		}
+	}
+
+	if n := source.count(); n != 1 {
+		t.Errorf("loaded %d times, want 1", n)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	source := &fakeSource{}
+	var handled []error
+	c, clk := newCatalog(source, WithTTL(time.Minute), WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	ctx := context.Background()
+
+	if _, err := c.Payers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(59 * time.Second)
+	if _, err := c.Payers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 1 {
+		t.Fatalf("loaded %d times within the TTL, want 1", n)
+	}
+
+	clk.advance(time.Second)
+	if _, err := c.Payers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := source.count(); n != 2 {
+		t.Fatalf("loaded %d times once expired, want 2", n)
+	}
+	if got := c.LoadedAt(); !got.Equal(clk.Now()) {
+		t.Errorf("LoadedAt() = %v, want %v", got, clk.Now())
+	}
+
+	// an expired catalog keeps being served while the source is unreachable
+	source.fail(errors.New("unreachable"))
+	clk.advance(time.Hour)
+	if payers, err := c.Payers(ctx); err != nil || len(payers) != 2 {
+		t.Errorf("Payers() = %d payers, %v, want the expired catalog", len(payers), err)
+	}
+	if len(handled) != 1 {
+		t.Errorf("%d errors handled, want 1", len(handled))
+	}
+}
+
+func TestConcurrentLookupsLoadOnce(t *testing.T) {
+	source := &fakeSource{}
+	c, _ := newCatalog(source)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Payer(context.Background(), 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := source.count(); n != 1 {
+		t.Errorf("loaded %d times, want 1", n)
+	}
+}
+
+func TestLoadWithoutSource(t *testing.T) {
+	source := &fakeSource{}
+	source.fail(errors.New("unreachable"))
+	c, _ := newCatalog(source)
+
+	if err := c.Load(context.Background()); err == nil {
+		t.Error("Load() without a source nor a snapshot succeeded")
+	}
+	if _, err := c.Payer(context.Background(), 1); err == nil {
+		t.Error("Payer() without a catalog succeeded")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	ctx := context.Background()
+
+	first, clk := newCatalog(&fakeSource{}, WithTTL(time.Minute), WithSnapshot(path))
+	if err := first.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// a fresh snapshot is served without loading the catalog
+	source := &fakeSource{}
+	second, _ := newCatalog(source, WithTTL(time.Minute), WithSnapshot(path))
+	second.now = clk.Now
+	if err := second.Load(ctx); err != nil {
+		t.Fatalf("Load() of the snapshot error = %v", err)
+	}
+	if n := source.count(); n != 0 {
+		t.Errorf("loaded %d times despite a fresh snapshot, want 0", n)
+	}
+	if got := second.LoadedAt(); !got.Equal(first.LoadedAt()) {
+		t.Errorf("LoadedAt() = %v, want %v", got, first.LoadedAt())
+	}
+	payers, err := second.FindPayers(ctx, Filter{CountryISOCode: "KEN"})
+	if err != nil || len(payers) != 1 || payers[0].Name != "M-Pesa Kenya" {
+		t.Errorf("FindPayers(KEN) = %+v, %v, want the payer of the snapshot", payers, err)
+	}
+	if payer, err := second.Payer(ctx, 2); err != nil || payer.TransactionTypes[pkg.B2B].RequiredDocuments[0][0] != "invoice" {
+		t.Errorf("Payer(2) = %+v, %v, want the payer of the snapshot", payer, err)
+	}
+
+	// a stale snapshot is kept when the source is unreachable
+	clk.advance(time.Hour)
+	var handled []error
+	unreachable := &fakeSource{}
+	unreachable.fail(errors.New("unreachable"))
+	third, _ := newCatalog(unreachable, WithTTL(time.Minute), WithSnapshot(path), WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	third.now = clk.Now
+	if err := third.Load(ctx); err != nil {
+		t.Fatalf("Load() of a stale snapshot error = %v", err)
+	}
+	if len(handled) != 1 {
+		t.Errorf("%d errors handled, want the refresh error", len(handled))
+	}
+	if _, err := third.Payer(ctx, 1); err != nil {
+		t.Errorf("Payer(1) error = %v, want the payer of the stale snapshot", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	source := &fakeSource{}
+	c := New(source, WithRefreshInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := source.count(); n < 2 {
+		t.Errorf("refreshed %d times, want several", n)
+	}
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// errNoSnapshot is returned by readSnapshot when the file does not exist yet.
+var errNoSnapshot = errors.New("catalog: no snapshot")
+
+// snapshot is a loaded catalog, indexed for the lookups. It is never modified once built.
+type snapshot struct {
+	LoadedAt  time.Time     `json:"loaded_at"`
+	Services  []pkg.Service `json:"services"`
+	Countries []pkg.Country `json:"countries"`
+	Payers    []pkg.Payer   `json:"payers"`
+
+	payersByID      map[int]int      // index of the payer in Payers
+	payersByCountry map[string][]int // indexes of the payers in Payers, by upper case country ISO code
+}
+
+func newSnapshot(loadedAt time.Time, services []pkg.Service, countries []pkg.Country, payers []pkg.Payer) *snapshot {
+	s := &snapshot{
+		LoadedAt:  loadedAt,
+		Services:  services,
+		Countries: countries,
+		Payers:    payers,
+	}
+	s.index()
+	return s
+}
+
+func (s *snapshot) index() {
+	s.payersByID = make(map[int]int, len(s.Payers))
+	s.payersByCountry = make(map[string][]int)
+	for i, payer := range s.Payers {
+		s.payersByID[payer.ID] = i
+		country := strings.ToUpper(payer.CountryISOCode)
+		s.payersByCountry[country] = append(s.payersByCountry[country], i)
+	}
+}
+
+// readSnapshot reads the catalog saved at path, it returns errNoSnapshot if there is none.
+func readSnapshot(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errNoSnapshot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("catalog: reading snapshot: %w", err)
+	}
+
+	s := &snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("catalog: decoding snapshot %s: %w", path, err)
+	}
+	s.index()
+	return s, nil
+}
+
+// writeSnapshot saves the catalog at path. The file is replaced atomically,
+// so that a process starting meanwhile never reads half of it.
+func writeSnapshot(path string, s *snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("catalog: encoding snapshot: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("catalog: writing snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("catalog: writing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("catalog: writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("catalog: writing snapshot: %w", err)
+	}
+	return nil
+}
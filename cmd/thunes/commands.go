@@ -0,0 +1,528 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/batch"
+	"thunes-client/pkg"
+)
+
+func runPing(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("ping")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	status, err := e.client.Ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"STATUS"}}
+	t.add(status.Status)
+	return e.print(status, t)
+}
+
+func runServices(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("services")
+	country := fs.String("country", "", "only list the services available in this country (ISO 3166-1 alpha-3)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	services, err := e.client.AllServices(ctx, optional(*country))
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "NAME"}}
+	for _, service := range services {
+		t.add(service.ID, service.Name)
+	}
+	return e.print(services, t)
+}
+
+func runPayers(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("payers")
+	serviceID := fs.Int("service", 0, "only list the payers of this service id")
+	country := fs.String("country", "", "only list the payers of this country (ISO 3166-1 alpha-3)")
+	currency := fs.String("currency", "", "only list the payers paying out in this currency")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var service *int
+	if *serviceID != 0 {
+		service = serviceID
+	}
+
+	payers, err := e.client.AllPayers(ctx, service, optional(*country), optional(*currency))
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "NAME", "COUNTRY", "CURRENCY", "SERVICE", "MIN", "MAX"}}
+	for _, payer := range payers {
+		t.add(payer.ID, payer.Name, payer.CountryISOCode, payer.Currency, payer.Service.Name, payer.MinTransactionAmount, payer.MaxTransactionAmount)
+	}
+	return e.print(payers, t)
+}
+
+func runPayer(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("payer")
+	id := fs.Int("id", 0, "id of the payer (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id", *id != 0); err != nil {
+		return err
+	}
+
+	payer, err := e.client.GetPayerDetails(ctx, *id)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "NAME", "COUNTRY", "CURRENCY", "SERVICE", "PRECISION", "INCREMENT", "MIN", "MAX"}}
+	t.add(payer.ID, payer.Name, payer.CountryISOCode, payer.Currency, payer.Service.Name, payer.Precision, payer.Increment, payer.MinTransactionAmount, payer.MaxTransactionAmount)
+	return e.print(payer, t)
+}
+
+func runRates(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("rates")
+	id := fs.Int("payer", 0, "id of the payer (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "payer", *id != 0); err != nil {
+		return err
+	}
+
+	rates, err := e.client.GetPayerRates(ctx, *id)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"TRANSACTION TYPE", "SOURCE CURRENCY", "DESTINATION CURRENCY", "SOURCE MIN", "SOURCE MAX", "WHOLESALE FX RATE"}}
+	for _, transactionType := range sortedKeys(rates.Rates) {
+		byCurrency := rates.Rates[transactionType]
+		currencies := make([]string, 0, len(byCurrency))
+		for currency := range byCurrency {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+
+		for _, currency := range currencies {
+			for _, rate := range byCurrency[currency] {
+				t.add(transactionType, currency, rates.DestinationCurrency, rate.SourceAmountMin, rate.SourceAmountMax, rate.WholesaleFXRate)
+			}
+		}
+	}
+	return e.print(rates, t)
+}
+
+func runCountries(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("countries")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	countries, err := e.client.AllCountries(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ISO CODE", "NAME"}}
+	for _, country := range countries {
+		t.add(country.ISOCode, country.Name)
+	}
+	return e.print(countries, t)
+}
+
+func runBalances(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("balances")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	balances, err := e.client.AllBalances(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "CURRENCY", "BALANCE", "PENDING", "AVAILABLE", "CREDIT FACILITY"}}
+	for _, balance := range balances {
+		t.add(balance.ID, balance.Currency, balance.Balance, balance.Pending, balance.Available, balance.CreditFacility)
+	}
+	return e.print(balances, t)
+}
+
+func runBICLookup(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("bic-lookup")
+	bic := fs.String("bic", "", "SWIFT BIC code (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bic", *bic != ""); err != nil {
+		return err
+	}
+
+	lookups, err := e.client.AllBICCodeLookups(ctx, *bic)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"PAYER ID"}}
+	for _, lookup := range lookups {
+		t.add(lookup.ID)
+	}
+	return e.print(lookups, t)
+}
+
+func runQuote(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("quote")
+	payerID := fs.Int("payer", 0, "id of the payer (required)")
+	mode := fs.String("mode", "source", "amount to fix: source or destination")
+	amount := fs.String("amount", "", "amount in the currency selected by -mode, e.g. 100.50 (required)")
+	transactionType := fs.String("type", "C2C", "transaction type: C2C, C2B, B2C or B2B")
+	sourceCurrency := fs.String("source-currency", "", "currency of the source amount (required)")
+	sourceCountry := fs.String("source-country", "", "country of the sender (ISO 3166-1 alpha-3, required)")
+	destinationCurrency := fs.String("destination-currency", "", "currency paid out (required)")
+	externalID := fs.String("external-id", "", "external id of the quotation (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		ok   bool
+	}{
+		{"payer", *payerID != 0},
+		{"amount", *amount != ""},
+		{"source-currency", *sourceCurrency != ""},
+		{"source-country", *sourceCountry != ""},
+		{"destination-currency", *destinationCurrency != ""},
+		{"external-id", *externalID != ""},
+	} {
+		if err := required(fs, f.name, f.ok); err != nil {
+			return err
+		}
+	}
+
+	value, err := pkg.ParseDecimal(*amount)
+	if err != nil {
+		return err
+	}
+	typ, err := pkg.ParseTransactionType(*transactionType)
+	if err != nil {
+		return err
+	}
+
+	var quotation *pkg.Quotation
+	payer := strconv.Itoa(*payerID)
+	switch *mode {
+	case "source":
+		quotation, err = e.client.CreateQuotationForSource(ctx, value, *destinationCurrency, *sourceCurrency, payer, *sourceCountry, typ, *externalID)
+	case "destination":
+		quotation, err = e.client.CreateQuotationForDestination(ctx, value, *destinationCurrency, *sourceCurrency, payer, *sourceCountry, typ, *externalID)
+	default:
+		return fmt.Errorf("unknown mode %q, use source or destination", *mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.print(quotation, quotationTable(quotation))
+}
+
+func runQuotation(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("quotation")
+	id := fs.Int("id", 0, "id of the quotation")
+	externalID := fs.String("external-id", "", "external id of the quotation")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id or -external-id", *id != 0 || *externalID != ""); err != nil {
+		return err
+	}
+
+	var (
+		quotation *pkg.Quotation
+		err       error
+	)
+	if *id != 0 {
+		quotation, err = e.client.GetQuotationByID(ctx, *id)
+	} else {
+		quotation, err = e.client.GetQuotationByExternalID(ctx, *externalID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.print(quotation, quotationTable(quotation))
+}
+
+func quotationTable(q *pkg.Quotation) *table {
+	t := &table{header: []string{"ID", "EXTERNAL ID", "PAYER", "MODE", "SOURCE", "DESTINATION", "SENT", "FEE", "RATE", "EXPIRES"}}
+	t.add(q.ID, q.ExternalID, q.Payer.ID, q.Mode, q.Source.Money(), q.Destination, q.SentAmount, q.Fee, q.WholeSaleFXRate, q.ExpirationDate)
+	return t
+}
+
+func runTransaction(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(e.stderr, "Usage: thunes transaction create|confirm|cancel|status|wait [flags]")
+		return errUsage
+	}
+
+	action, args := args[0], args[1:]
+	if action == "create" {
+		return runTransactionCreate(ctx, e, args)
+	}
+
+	fs := e.newFlagSet("transaction " + action)
+	id := fs.Int("id", 0, "id of the transaction")
+	externalID := fs.String("external-id", "", "external id of the transaction")
+	interval := fs.Duration("interval", api.DefaultPollInterval, "initial delay between two polls of wait")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id or -external-id", *id != 0 || *externalID != ""); err != nil {
+		return err
+	}
+
+	ref, extRef := transactionRef(*id, *externalID)
+
+	var (
+		transaction *pkg.Transaction
+		err         error
+	)
+	switch action {
+	case "confirm":
+		transaction, err = e.client.ConfirmTransaction(ctx, ref, extRef)
+	case "cancel":
+		transaction, err = e.client.CancelTransaction(ctx, ref, extRef)
+	case "status":
+		transaction, err = e.client.GetTransactionInformation(ctx, ref, extRef)
+	case "wait":
+		transaction, err = waitTransaction(ctx, e, ref, extRef, *interval)
+	default:
+		fmt.Fprintf(e.stderr, "thunes: unknown transaction action %q, use create, confirm, cancel, status or wait\n", action)
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.print(transaction, transactionTable(transaction))
+}
+
+// waitTransaction polls the transaction until its status is final, reporting every change on stderr.
+func waitTransaction(ctx context.Context, e *env, id *int, externalID *string, interval time.Duration) (*pkg.Transaction, error) {
+	var last *pkg.Transaction
+	for change := range e.client.Watch(ctx, id, externalID, api.PollInterval(interval, api.DefaultMaxPollInterval)) {
+		if change.Err != nil {
+			return nil, change.Err
+		}
+		last = change.Transaction
+		fmt.Fprintf(e.stderr, "%s %s\n", format(last.Status), format(last.StatusMessage))
+	}
+	if last == nil || !last.Status.IsFinal() {
+		return nil, ctx.Err()
+	}
+	return last, nil
+}
+
+func runTransactionCreate(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("transaction create")
+	quotationID := fs.Int("quotation-id", 0, "id of the quotation")
+	quotationExternalID := fs.String("quotation-external-id", "", "external id of the quotation")
+	body := fs.String("body", "", "JSON file holding the transaction request, - reads it from stdin")
+	externalID := fs.String("external-id", "", "external id of the transaction, overrides the body")
+	msisdn := fs.String("msisdn", "", "MSISDN of the credit party, overrides the body")
+	callbackURL := fs.String("callback-url", "", "callback url of the transaction, overrides the body")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "quotation-id or -quotation-external-id", *quotationID != 0 || *quotationExternalID != ""); err != nil {
+		return err
+	}
+
+	var req pkg.CreateTransactionRequest
+	if *body != "" {
+		if err := readJSON(*body, &req); err != nil {
+			return err
+		}
+	}
+	if *externalID != "" {
+		req.ExternalID = externalID
+	}
+	if *msisdn != "" {
+		if req.CreditPartyIdentifier == nil {
+			req.CreditPartyIdentifier = &pkg.CreditPartyIdentifier{}
+		}
+		req.CreditPartyIdentifier.MSISDN = *msisdn
+	}
+	if *callbackURL != "" {
+		req.CallbackURL = callbackURL
+	}
+	if req.ExternalID == nil {
+		return errors.New("the transaction needs an external id, use -external-id or the body")
+	}
+
+	ref, extRef := transactionRef(*quotationID, *quotationExternalID)
+	transaction, err := e.client.CreateTransaction(ctx, &req, ref, extRef)
+	if err != nil {
+		return err
+	}
+
+	return e.print(transaction, transactionTable(transaction))
+}
+
+func transactionTable(tx *pkg.Transaction) *table {
+	t := &table{header: []string{"ID", "EXTERNAL ID", "STATUS", "STATUS MESSAGE", "STATUS CLASS", "PAYER REFERENCE", "SENT", "DESTINATION", "CREATED"}}
+	t.add(tx.ID, tx.ExternalID, tx.Status, tx.StatusMessage, tx.StatusClassMessage, tx.PayerTransactionReference, tx.SendAmount, tx.Destination, tx.CreationDate)
+	return t
+}
+
+func runAttach(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("attach")
+	id := fs.Int("id", 0, "id of the transaction")
+	externalID := fs.String("external-id", "", "external id of the transaction")
+	attachmentType := fs.String("type", string(pkg.INVOICE), "type of the document: invoice, purchase_order, delivery_slip or contract")
+	path := fs.String("file", "", "path of the document (required)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id or -external-id", *id != 0 || *externalID != ""); err != nil {
+		return err
+	}
+	if err := required(fs, "file", *path != ""); err != nil {
+		return err
+	}
+
+	typ, err := pkg.ParseTransactionAttachmentType(*attachmentType)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ref, extRef := transactionRef(*id, *externalID)
+	attachment, err := e.client.AddAttachmentToTransaction(ctx, file.Name(), typ, file, ref, extRef)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "TRANSACTION ID", "NAME", "TYPE", "CONTENT TYPE"}}
+	t.add(attachment.ID, attachment.TransactionID, attachment.Name, attachment.Type, attachment.ContentType)
+	return e.print(attachment, t)
+}
+
+func runBatch(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("batch")
+	input := fs.String("file", "", "path of the payout file, CSV if its extension is .csv, JSONL otherwise (required)")
+	results := fs.String("results", "", "path of the result file, CSV if its extension is .csv, JSONL otherwise (default <file>.results<ext>)")
+	concurrency := fs.Int("concurrency", batch.DefaultConcurrency, "number of payouts sent at the same time")
+	rate := fs.Float64("rate", 0, "maximum number of payouts started per second, 0 for no limit")
+	skipBalance := fs.Bool("skip-balance-check", false, "do not check the balance before each transaction")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "file", *input != ""); err != nil {
+		return err
+	}
+	if *results == "" {
+		ext := filepath.Ext(*input)
+		*results = strings.TrimSuffix(*input, ext) + ".results" + ext
+	}
+
+	opts := []batch.Option{batch.WithConcurrency(*concurrency), batch.WithRateLimit(*rate)}
+	if *skipBalance {
+		opts = append(opts, batch.WithoutBalanceCheck())
+	}
+	summary, err := batch.NewRunner(e.client, opts...).RunFile(ctx, *input, *results)
+	if summary == nil {
+		return err
+	}
+
+	t := &table{header: []string{"TOTAL", "CONFIRMED", "FAILED", "INVALID", "SKIPPED", "RESULTS"}}
+	t.add(summary.Total, summary.Confirmed, summary.Failed, summary.Invalid, summary.Skipped, *results)
+	if printErr := e.print(summary, t); err == nil {
+		err = printErr
+	}
+	return err
+}
+
+// required fails with the usage of the command when a required flag is missing.
+func required(fs *flag.FlagSet, name string, ok bool) error {
+	if ok {
+		return nil
+	}
+
+	fmt.Fprintf(fs.Output(), "missing -%s\n", name)
+	fs.Usage()
+	return errUsage
+}
+
+// optional converts an empty flag value to nil.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// transactionRef converts id flags to the references taken by the client, the id wins over the external id.
+func transactionRef(id int, externalID string) (*int, *string) {
+	if id != 0 {
+		return &id, nil
+	}
+	return nil, &externalID
+}
+
+// readJSON decodes the JSON file at path into v, "-" reads stdin.
+func readJSON(path string, v interface{}) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[pkg.TransactionType]map[string][]pkg.Rates) []pkg.TransactionType {
+	keys := make([]pkg.TransactionType, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"thunes-client/api"
+)
+
+// config holds the settings of the thunes command.
+//
+// They are read from a JSON file, which can hold the credentials as well:
+//
+//	{
+//		"api_key": "...",
+//		"api_secret": "...",
+//		"environment": "preproduction"
+//	}
+//
+// The THUNES_ENV and THUNES_BASE_URL environment variables take precedence over the file,
+// and so do THUNES_API_KEY and THUNES_API_SECRET for the credentials. A config file holding
+// credentials must only be readable by its owner.
+type config struct {
+	Environment string `json:"environment"`
+	BaseURL     string `json:"base_url"`
+
+	// path is the config file, the credentials are read from it by an api.FileProvider.
+	path string
+}
+
+// loadConfig reads the config file at path, or at the default location if path is empty,
+// and applies the environment variables on top of it.
+func loadConfig(path string) (*config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	cfg := &config{path: path}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("reading config file %s: %w", path, err)
+			}
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+			// the default config file is optional
+		default:
+			return nil, err
+		}
+	}
+
+	for name, field := range map[string]*string{
+		"THUNES_ENV":      &cfg.Environment,
+		"THUNES_BASE_URL": &cfg.BaseURL,
+	} {
+		if value := os.Getenv(name); value != "" {
+			*field = value
+		}
+	}
+
+	return cfg, nil
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("THUNES_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "thunes", "config.json")
+}
+
+// credentials returns the provider of the credentials: the environment variables, then the config file.
+func (cfg *config) credentials() api.CredentialsProvider {
+	chain := api.NewChainProvider(api.EnvProvider{})
+	if cfg.path != "" {
+		chain = append(chain, api.NewFileProvider(cfg.path))
+	}
+	return chain
+}
+
+// client constructs the Thunes client described by the config, with extra options.
+func (cfg *config) client(ctx context.Context, extra ...api.Option) (*api.ThunesClient, error) {
+	creds := cfg.credentials()
+
+	// fail early rather than on the first request
+	if _, err := creds.Credentials(ctx); err != nil {
+		if errors.Is(err, api.ErrNoCredentials) {
+			return nil, errors.New("missing credentials: set THUNES_API_KEY and THUNES_API_SECRET or use a config file")
+		}
+		return nil, err
+	}
+
+	opts := []api.Option{api.WithUserAgent("thunes-cli"), api.WithCredentialsProvider(creds)}
+	switch strings.ToLower(cfg.Environment) {
+	case "", "preproduction", "pre":
+		opts = append(opts, api.WithEnvironment(api.Preproduction))
+	case "production", "prod":
+		opts = append(opts, api.WithEnvironment(api.Production))
+	default:
+		return nil, fmt.Errorf("unknown environment %q, use preproduction or production", cfg.Environment)
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, api.WithBaseURL(cfg.BaseURL))
+	}
+	opts = append(opts, extra...)
+
+	return api.NewThunesClient("", "", opts...), nil
+}
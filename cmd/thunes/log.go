@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logger writes the logs of the client to stderr, one line per record: time, level, message, then key=value pairs.
+type logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *logger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args) }
+func (l *logger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args) }
+func (l *logger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args) }
+func (l *logger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args) }
+
+func (l *logger) log(level, msg string, args []interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s %s", time.Now().Format("15:04:05.000"), level, msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		value := fmt.Sprint(args[i+1])
+		if value == "" || strings.ContainsAny(value, " \"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %v=%s", args[i], value)
+	}
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
@@ -0,0 +1,156 @@
+// Command thunes is a command line client for the Thunes money transfer API.
+//
+// Usage:
+//
+//	thunes [global flags] <command> [flags]
+//
+// Credentials are read from the THUNES_API_KEY and THUNES_API_SECRET environment variables,
+// or from a JSON config file (see config.go). Run "thunes help" for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"thunes-client/api"
+)
+
+// command is a subcommand of the thunes command.
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"ping":        {"check the credentials and the availability of the API", runPing},
+	"services":    {"list the services available", runServices},
+	"payers":      {"list the payers available", runPayers},
+	"payer":       {"show the details of a payer", runPayer},
+	"rates":       {"show the rates of a payer", runRates},
+	"countries":   {"list the countries available", runCountries},
+	"balances":    {"show the account balances", runBalances},
+	"bic-lookup":  {"list the payers of a SWIFT BIC code", runBICLookup},
+	"quote":       {"create a quotation", runQuote},
+	"quotation":   {"show a quotation", runQuotation},
+	"transaction": {"create, confirm, cancel, show or wait for a transaction (create|confirm|cancel|status|wait)", runTransaction},
+	"attach":      {"attach a document to a transaction", runAttach},
+	"batch":       {"send the payouts of a CSV or JSONL file, resuming an interrupted batch", runBatch},
+}
+
+// errUsage reports invalid arguments, the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "thunes:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("thunes", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { usage(stderr, global) }
+
+	var (
+		configPath  = global.String("config", "", "path of the config file (default $THUNES_CONFIG or ~/.config/thunes/config.json)")
+		environment = global.String("env", "", "environment to use: preproduction or production")
+		baseURL     = global.String("base-url", "", "custom base url of the API")
+		output      = outputFormat("table")
+		verbose     = global.Bool("verbose", false, "log the requests to stderr, with their bodies and personal data masked")
+	)
+	global.Var(&output, "output", "output `format`: table, json or csv")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	if global.NArg() == 0 || global.Arg(0) == "help" {
+		usage(stdout, global)
+		return nil
+	}
+
+	cmd, ok := commands[global.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "thunes: unknown command %q\n\n", global.Arg(0))
+		usage(stderr, global)
+		return errUsage
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *environment != "" {
+		cfg.Environment = *environment
+	}
+	if *baseURL != "" {
+		cfg.BaseURL = *baseURL
+	}
+
+	var opts []api.Option
+	if *verbose {
+		opts = append(opts, api.WithLogger(&logger{w: stderr}), api.WithBodyLogging())
+	}
+	tc, err := cfg.client(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	e := &env{client: tc, output: output, stdout: stdout, stderr: stderr}
+	return cmd.run(ctx, e, global.Args()[1:])
+}
+
+func usage(w io.Writer, global *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: thunes [global flags] <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(w, "\nGlobal flags:")
+	global.SetOutput(w)
+	global.PrintDefaults()
+	fmt.Fprintln(w, "\nRun \"thunes <command> -h\" for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of a subcommand, accepting the output flag as well.
+func (e *env) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("thunes "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Var(&e.output, "output", "output `format`: table, json or csv")
+	return fs
+}
+
+// parse parses the flags of a subcommand and checks no positional argument is left.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
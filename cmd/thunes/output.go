@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+// env is what the commands run with.
+type env struct {
+	client *api.ThunesClient
+	output outputFormat
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// outputFormat is the value of the output flags, checked as they are parsed so that a command
+// never runs with an output it cannot print.
+type outputFormat string
+
+func (o *outputFormat) String() string {
+	return string(*o)
+}
+
+func (o *outputFormat) Set(s string) error {
+	switch s {
+	case "table", "json", "csv":
+		*o = outputFormat(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use table, json or csv", s)
+	}
+}
+
+// table is the tabular view of a command's result, used by the table and csv outputs.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = format(cell)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// print writes the result of a command: v as is for the json output, its table view otherwise.
+func (e *env) print(v interface{}, t *table) error {
+	switch e.output {
+	case "json":
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "csv":
+		w := csv.NewWriter(e.stdout)
+		if err := w.Write(t.header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(t.rows); err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	case "table":
+		w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", e.output)
+	}
+}
+
+// format renders a cell, dereferencing pointers and showing nil as an empty cell.
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case *int64:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case pkg.Timestamp:
+		return format(v.Time)
+	default:
+		// other pointers, e.g. to the typed enums of pkg
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return ""
+			}
+			return format(rv.Elem().Interface())
+		}
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,269 @@
+// Package fx estimates the amounts of a quotation offline, from the tiered wholesale FX rates
+// published by the payers, so that indicative quotes can be shown without creating a quotation.
+//
+// The rates of a payer are fetched once with GetPayerRates and cached, or set with SetRates:
+//
+//	est := fx.NewEstimator(client, fx.WithTTL(10*time.Minute))
+//	e, err := est.EstimateSource(ctx, payer, pkg.C2C, pkg.NewMoney(pkg.MustParseDecimal("100"), "USD"))
+//	fmt.Println(e.Destination) // 12850.00 KES
+//
+// Estimates do not include the fee and the FX rate may have moved by the time the quotation is created.
+// Create a quotation to get the amounts of a transaction.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"thunes-client/pkg"
+)
+
+// DefaultTTL is how long the rates of a payer are cached.
+const DefaultTTL = 15 * time.Minute
+
+var (
+	// ErrNoRate is returned when the payer has no rate for the transaction type, source currency and amount.
+	ErrNoRate = errors.New("fx: no rate available")
+	// ErrOutOfLimits is returned when the destination amount is out of the payer's limits.
+	ErrOutOfLimits = errors.New("fx: amount out of the payer's limits")
+	// ErrInvalidAmount is returned when the destination amount is not a multiple of the payer's increment.
+	ErrInvalidAmount = errors.New("fx: invalid amount")
+)
+
+// RatesSource fetches the rates of a payer, *api.ThunesClient implements it.
+type RatesSource interface {
+	GetPayerRates(ctx context.Context, id int) (*pkg.PayerRates, error)
+}
+
+// Estimate is an indicative quote, computed from the rates of a payer.
+type Estimate struct {
+	PayerID         int
+	TransactionType pkg.TransactionType
+	Mode            pkg.QuotationMode
+	// Source is the amount sent, excluding the fee.
+	Source pkg.Money
+	// Destination is the amount paid out.
+	Destination     pkg.Money
+	WholesaleFXRate pkg.Decimal
+	// Tier is the tier of the rates the estimate was computed with.
+	Tier pkg.Rates
+}
+
+// Estimator computes estimates from the rates of the payers, safe for concurrent use.
+type Estimator struct {
+	source RatesSource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	rates map[int]cachedRates
+}
+
+type cachedRates struct {
+	rates   *pkg.PayerRates
+	expires time.Time
+}
+
+// Option configures an Estimator.
+type Option func(*Estimator)
+
+// WithTTL sets how long the rates fetched from the source are cached, it defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *Estimator) {
+		if ttl > 0 {
+			e.ttl = ttl
+		}
+	}
+}
+
+// NewEstimator constructs an estimator fetching the rates it has not cached from source.
+// A nil source makes it fully offline: only the rates set with SetRates are used.
+func NewEstimator(source RatesSource, opts ...Option) *Estimator {
+	e := &Estimator{
+		source: source,
+		ttl:    DefaultTTL,
+		now:    time.Now,
+		rates:  make(map[int]cachedRates),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// SetRates caches the rates of a payer, e.g. loaded from a file. Rates set this way never expire.
+func (e *Estimator) SetRates(payerID int, rates *pkg.PayerRates) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rates[payerID] = cachedRates{rates: rates}
+}
+
+// Rates returns the rates of the payer, from the cache or else from the source.
+func (e *Estimator) Rates(ctx context.Context, payerID int) (*pkg.PayerRates, error) {
+	e.mu.Lock()
+	cached, ok := e.rates[payerID]
+	e.mu.Unlock()
+	if ok && (cached.expires.IsZero() || e.now().Before(cached.expires)) {
+		return cached.rates, nil
+	}
+	if e.source == nil {
+		return nil, fmt.Errorf("%w: no rates for payer %d", ErrNoRate, payerID)
+	}
+
+	rates, err := e.source.GetPayerRates(ctx, payerID)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.rates[payerID] = cachedRates{rates: rates, expires: e.now().Add(e.ttl)}
+	e.mu.Unlock()
+
+	return rates, nil
+}
+
+// EstimateSource estimates the amount paid out by the payer for the amount sent.
+// The destination amount is rounded down to the payer's precision and increment.
+func (e *Estimator) EstimateSource(ctx context.Context, payer *pkg.Payer, transactionType pkg.TransactionType, source pkg.Money) (*Estimate, error) {
+	tiers, err := e.tiers(ctx, payer, transactionType, source.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	tier, ok := findTier(tiers, source.Amount)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is out of the tiers of payer %d", ErrNoRate, source, payer.ID)
+	}
+
+	destination := pkg.NewMoney(roundDown(source.Amount.Mul(tier.WholesaleFXRate), payer), payer.Currency)
+	if err := checkLimits(payer, transactionType, destination); err != nil {
+		return nil, err
+	}
+
+	return &Estimate{
+		PayerID:         payer.ID,
+		TransactionType: transactionType,
+		Mode:            pkg.SourceAmount,
+		Source:          source,
+		Destination:     destination,
+		WholesaleFXRate: tier.WholesaleFXRate,
+		Tier:            tier,
+	}, nil
+}
+
+// EstimateDestination estimates the amount to send in sourceCurrency for the payer to pay out the destination amount,
+// which must be a multiple of the payer's increment. The source amount is rounded up to the minor units of its currency,
+// so that it always covers the destination amount.
+func (e *Estimator) EstimateDestination(ctx context.Context, payer *pkg.Payer, transactionType pkg.TransactionType, sourceCurrency string, destination pkg.Money) (*Estimate, error) {
+	if !strings.EqualFold(destination.Currency, payer.Currency) {
+		return nil, fmt.Errorf("%w: payer %d pays out %s", pkg.ErrCurrencyMismatch, payer.ID, payer.Currency)
+	}
+	if !roundDown(destination.Amount, payer).Equal(destination.Amount) {
+		return nil, fmt.Errorf("%w: %s is not a multiple of the increment %s of payer %d", ErrInvalidAmount, destination, payer.Increment, payer.ID)
+	}
+	if err := checkLimits(payer, transactionType, destination); err != nil {
+		return nil, err
+	}
+
+	tiers, err := e.tiers(ctx, payer, transactionType, sourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tier depends on the source amount, which depends on the rate of the tier:
+	// use the first tier whose rate gives a source amount within its bounds
+	units := pkg.MinorUnits(sourceCurrency)
+	for _, tier := range tiers {
+		amount := destination.Amount.Div(tier.WholesaleFXRate, units)
+		if amount.Mul(tier.WholesaleFXRate).Cmp(destination.Amount) < 0 {
+			amount = amount.Add(pkg.NewDecimal(1, units))
+		}
+		if t, ok := findTier([]pkg.Rates{tier}, amount); ok {
+			return &Estimate{
+				PayerID:         payer.ID,
+				TransactionType: transactionType,
+				Mode:            pkg.DestinationAmount,
+				Source:          pkg.NewMoney(amount, sourceCurrency),
+				Destination:     destination,
+				WholesaleFXRate: t.WholesaleFXRate,
+				Tier:            t,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is out of the tiers of payer %d", ErrNoRate, destination, payer.ID)
+}
+
+// tiers returns the tiers of the rates of the payer for the transaction type and source currency.
+func (e *Estimator) tiers(ctx context.Context, payer *pkg.Payer, transactionType pkg.TransactionType, sourceCurrency string) ([]pkg.Rates, error) {
+	if err := transactionType.Validate(); err != nil {
+		return nil, err
+	}
+
+	rates, err := e.Rates(ctx, payer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for currency, tiers := range rates.Rates[transactionType] {
+		if strings.EqualFold(currency, sourceCurrency) && len(tiers) > 0 {
+			return tiers, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: payer %d has no %s rate from %s", ErrNoRate, payer.ID, transactionType, sourceCurrency)
+}
+
+// findTier returns the tier of the source amount. The bounds of a tier are [min, max),
+// except for the last one which includes its max.
+func findTier(tiers []pkg.Rates, amount pkg.Decimal) (pkg.Rates, bool) {
+	for i, tier := range tiers {
+		if amount.Cmp(tier.SourceAmountMin) < 0 {
+			continue
+		}
+		if c := amount.Cmp(tier.SourceAmountMax); c < 0 || (c == 0 && i == len(tiers)-1) {
+			return tier, true
+		}
+	}
+	return pkg.Rates{}, false
+}
+
+// roundDown rounds the amount down to the precision and to a multiple of the increment of the payer.
+func roundDown(amount pkg.Decimal, payer *pkg.Payer) pkg.Decimal {
+	precision := int32(payer.Precision)
+	if precision == 0 && payer.Increment.Sign() == 0 {
+		precision = pkg.MinorUnits(payer.Currency)
+	}
+	amount = amount.Truncate(precision)
+
+	if payer.Increment.Sign() > 0 {
+		steps := amount.DivTruncate(payer.Increment, 0)
+		amount = steps.Mul(payer.Increment).Round(precision)
+	}
+	return amount
+}
+
+// checkLimits checks the destination amount against the limits of the payer for the transaction type,
+// or its general limits if it does not publish limits for the transaction type.
+func checkLimits(payer *pkg.Payer, transactionType pkg.TransactionType, destination pkg.Money) error {
+	min, max := payer.MinTransactionAmount, payer.MaxTransactionAmount
+	if info, ok := payer.TransactionTypes[transactionType]; ok {
+		if info.MinTransactionAmount.Sign() > 0 {
+			min = info.MinTransactionAmount
+		}
+		if info.MaxTransactionAmount.Sign() > 0 {
+			max = info.MaxTransactionAmount
+		}
+	}
+
+	if destination.Amount.Cmp(min) < 0 || (max.Sign() > 0 && destination.Amount.Cmp(max) > 0) {
+		return fmt.Errorf("%w: %s is not between %s and %s", ErrOutOfLimits, destination, min, max)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package fx
+
+import (
+	"context"
+	"testing"
+
+	"thunes-client/pkg"
+)
+
+func TestRoundDown(t *testing.T) {
+	tests := []struct {
+		amount    string
+		precision int
+		increment string
+		currency  string
+		want      string
+	}{
+		{amount: "14.99", precision: 2, increment: "5", want: "10.00"},
+		{amount: "15", precision: 2, increment: "5", want: "15.00"},
+		{amount: "4.999", precision: 2, increment: "5", want: "0.00"},
+		{amount: "1234.567", precision: 2, increment: "0.01", want: "1234.56"},
+		{amount: "1234.567", precision: 2, increment: "0.05", want: "1234.55"},
+		{amount: "1234.567", precision: 0, increment: "100", want: "1200"},
+		{amount: "1234.567", currency: "USD", want: "1234.56"},
+		{amount: "1234.567", currency: "JPY", want: "1234"},
+	}
+
+	for _, tt := range tests {
+		payer := &pkg.Payer{Precision: tt.precision, Currency: tt.currency}
+		if tt.increment != "" {
+			payer.Increment = pkg.MustParseDecimal(tt.increment)
+		}
+		got := roundDown(pkg.MustParseDecimal(tt.amount), payer)
+		if got.String() != tt.want {
+			t.Errorf("roundDown(%s, precision %d, increment %s) = %s, want %s", tt.amount, tt.precision, tt.increment, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateSourceRoundsDownToIncrement(t *testing.T) {
+	payer := &pkg.Payer{ID: 1, Precision: 2, Increment: pkg.MustParseDecimal("5"), Currency: "KES"}
+	e := NewEstimator(nil)
+	e.SetRates(1, &pkg.PayerRates{
+		DestinationCurrency: "KES",
+		Rates: map[pkg.TransactionType]map[string][]pkg.Rates{
+			pkg.C2C: {
+				"USD": {{SourceAmountMin: pkg.DecimalFromInt(0), SourceAmountMax: pkg.DecimalFromInt(1000), WholesaleFXRate: pkg.MustParseDecimal("1.499")}},
+			},
+		},
+	})
+
+	estimate, err := e.EstimateSource(context.Background(), payer, pkg.C2C, pkg.NewMoney(pkg.DecimalFromInt(10), "USD"))
+	if err != nil {
+		t.Fatalf("EstimateSource() error = %v", err)
+	}
+	if got := estimate.Destination.Amount.String(); got != "10.00" {
+		t.Errorf("destination = %s, want 10.00", got)
+	}
+}
@@ -0,0 +1,316 @@
+// Package metrics collects the metrics of Thunes clients in memory and exposes them, in the Prometheus
+// text format through an http.Handler and as JSON through expvar, without any external service:
+//
+//	collector := metrics.NewCollector()
+//	client := api.NewThunesClient(key, secret, api.WithMetrics(collector))
+//	http.Handle("/metrics", collector)
+//	collector.Publish("thunes")
+//
+// It counts the requests by endpoint, method and status, their retries and the Thunes error codes of their
+// failures, measures their latency with a histogram per endpoint, and sums the amounts of the confirmed payouts
+// by currency.
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histograms.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// statusError is the status of the requests which received no response.
+const statusError = "error"
+
+// Collector records the metrics reported by clients configured with api.WithMetrics.
+// It is safe for concurrent use, and can be shared by several clients.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	latencies map[api.Endpoint]*histogram
+	retries   map[api.Endpoint]uint64
+	errors    map[errorKey]uint64
+	payouts   map[payoutKey]uint64
+	amounts   map[string]*amounts
+}
+
+type requestKey struct {
+	endpoint api.Endpoint
+	method   string
+	status   string
+}
+
+type errorKey struct {
+	endpoint api.Endpoint
+	code     string
+}
+
+type payoutKey struct {
+	transactionType pkg.TransactionType
+	sourceCurrency  string
+	destCurrency    string
+}
+
+type histogram struct {
+	// counts holds the number of observations of each bucket, not cumulative
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type amounts struct {
+	sent pkg.Decimal
+	paid pkg.Decimal
+}
+
+// Option configures a Collector.
+type Option func(*Collector)
+
+// WithNamespace sets the prefix of the names of the metrics, it defaults to "thunes".
+func WithNamespace(namespace string) Option {
+	return func(c *Collector) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the upper bounds, in seconds, of the latency histograms, it defaults to DefaultBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(c *Collector) {
+		if len(buckets) > 0 {
+			c.buckets = append([]float64(nil), buckets...)
+			sort.Float64s(c.buckets)
+		}
+	}
+}
+
+// NewCollector constructs an empty collector.
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		namespace: "thunes",
+		buckets:   DefaultBuckets,
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[api.Endpoint]*histogram),
+		retries:   make(map[api.Endpoint]uint64),
+		errors:    make(map[errorKey]uint64),
+		payouts:   make(map[payoutKey]uint64),
+		amounts:   make(map[string]*amounts),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// ObserveRequest records an attempt of a request, it implements api.MetricsHook.
+func (c *Collector) ObserveRequest(m api.RequestMetrics) {
+	status := statusError
+	if m.StatusCode != 0 {
+		status = strconv.Itoa(m.StatusCode)
+	}
+	seconds := m.Duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[requestKey{endpoint: m.Endpoint, method: m.Method, status: status}]++
+	if m.Attempt > 1 {
+		c.retries[m.Endpoint]++
+	}
+	for _, code := range m.ErrorCodes {
+		c.errors[errorKey{endpoint: m.Endpoint, code: code}]++
+	}
+
+	h, ok := c.latencies[m.Endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.latencies[m.Endpoint] = h
+	}
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ObservePayout records a confirmed transaction, it implements api.MetricsHook.
+func (c *Collector) ObservePayout(m api.PayoutMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.payouts[payoutKey{transactionType: m.TransactionType, sourceCurrency: m.Source.Currency, destCurrency: m.Destination.Currency}]++
+	if m.Source.Currency != "" {
+		c.amount(m.Source.Currency).sent = c.amount(m.Source.Currency).sent.Add(m.Source.Amount)
+	}
+	if m.Destination.Currency != "" {
+		c.amount(m.Destination.Currency).paid = c.amount(m.Destination.Currency).paid.Add(m.Destination.Amount)
+	}
+}
+
+// amount returns the amounts of the currency, it must be called with c.mu held.
+func (c *Collector) amount(currency string) *amounts {
+	a, ok := c.amounts[currency]
+	if !ok {
+		a = &amounts{}
+		c.amounts[currency] = a
+	}
+	return a
+}
+
+// Reset forgets every metric recorded.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = make(map[requestKey]uint64)
+	c.latencies = make(map[api.Endpoint]*histogram)
+	c.retries = make(map[api.Endpoint]uint64)
+	c.errors = make(map[errorKey]uint64)
+	c.payouts = make(map[payoutKey]uint64)
+	c.amounts = make(map[string]*amounts)
+}
+
+// Snapshot is a copy of the metrics of a Collector, sorted by labels.
+type Snapshot struct {
+	Time      time.Time      `json:"time"`
+	Requests  []RequestCount `json:"requests"`
+	Latencies []Histogram    `json:"latencies"`
+	Retries   []RetryCount   `json:"retries"`
+	Errors    []ErrorCount   `json:"errors"`
+	Payouts   []PayoutCount  `json:"payouts"`
+	Amounts   []Amount       `json:"amounts"`
+}
+
+// RequestCount is the number of attempts of the requests to an endpoint with a method and a status.
+// The status is the HTTP status code, or "error" when no response was received.
+type RequestCount struct {
+	Endpoint api.Endpoint `json:"endpoint"`
+	Method   string       `json:"method"`
+	Status   string       `json:"status"`
+	Count    uint64       `json:"count"`
+}
+
+// Histogram is the distribution of the latency of the requests to an endpoint, in seconds.
+type Histogram struct {
+	Endpoint api.Endpoint `json:"endpoint"`
+	// Buckets holds the cumulative counts of the requests faster than each bound, Count is the +Inf bucket.
+	Buckets []Bucket `json:"buckets"`
+	Sum     float64  `json:"sum"`
+	Count   uint64   `json:"count"`
+}
+
+// Bucket is a bucket of a Histogram.
+type Bucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// RetryCount is the number of retries of the requests to an endpoint.
+type RetryCount struct {
+	Endpoint api.Endpoint `json:"endpoint"`
+	Count    uint64       `json:"count"`
+}
+
+// ErrorCount is the number of failed requests to an endpoint reporting a Thunes error code.
+type ErrorCount struct {
+	Endpoint api.Endpoint `json:"endpoint"`
+	Code     string       `json:"code"`
+	Count    uint64       `json:"count"`
+}
+
+// PayoutCount is the number of confirmed payouts of a transaction type between two currencies.
+type PayoutCount struct {
+	TransactionType     pkg.TransactionType `json:"transaction_type"`
+	SourceCurrency      string              `json:"source_currency"`
+	DestinationCurrency string              `json:"destination_currency"`
+	Count               uint64              `json:"count"`
+}
+
+// Amount is the total of the confirmed payouts in a currency: Sent when it was the source currency,
+// Paid when it was the destination currency.
+type Amount struct {
+	Currency string      `json:"currency"`
+	Sent     pkg.Decimal `json:"sent"`
+	Paid     pkg.Decimal `json:"paid"`
+}
+
+// Snapshot returns a copy of the metrics recorded so far.
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Snapshot{Time: time.Now()}
+	for key, count := range c.requests {
+		s.Requests = append(s.Requests, RequestCount{Endpoint: key.endpoint, Method: key.method, Status: key.status, Count: count})
+	}
+	sort.Slice(s.Requests, func(i, j int) bool {
+		a, b := s.Requests[i], s.Requests[j]
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+
+	for endpoint, h := range c.latencies {
+		hist := Histogram{Endpoint: endpoint, Sum: h.sum, Count: h.count, Buckets: make([]Bucket, len(c.buckets))}
+		var cumulative uint64
+		for i, bound := range c.buckets {
+			cumulative += h.counts[i]
+			hist.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+		}
+		s.Latencies = append(s.Latencies, hist)
+	}
+	sort.Slice(s.Latencies, func(i, j int) bool { return s.Latencies[i].Endpoint < s.Latencies[j].Endpoint })
+
+	for endpoint, count := range c.retries {
+		s.Retries = append(s.Retries, RetryCount{Endpoint: endpoint, Count: count})
+	}
+	sort.Slice(s.Retries, func(i, j int) bool { return s.Retries[i].Endpoint < s.Retries[j].Endpoint })
+
+	for key, count := range c.errors {
+		s.Errors = append(s.Errors, ErrorCount{Endpoint: key.endpoint, Code: key.code, Count: count})
+	}
+	sort.Slice(s.Errors, func(i, j int) bool {
+		a, b := s.Errors[i], s.Errors[j]
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Code < b.Code
+	})
+
+	for key, count := range c.payouts {
+		s.Payouts = append(s.Payouts, PayoutCount{TransactionType: key.transactionType, SourceCurrency: key.sourceCurrency, DestinationCurrency: key.destCurrency, Count: count})
+	}
+	sort.Slice(s.Payouts, func(i, j int) bool {
+		a, b := s.Payouts[i], s.Payouts[j]
+		if a.TransactionType != b.TransactionType {
+			return a.TransactionType < b.TransactionType
+		}
+		if a.SourceCurrency != b.SourceCurrency {
+			return a.SourceCurrency < b.SourceCurrency
+		}
+		return a.DestinationCurrency < b.DestinationCurrency
+	})
+
+	for currency, a := range c.amounts {
+		s.Amounts = append(s.Amounts, Amount{Currency: currency, Sent: a.sent, Paid: a.paid})
+	}
+	sort.Slice(s.Amounts, func(i, j int) bool { return s.Amounts[i].Currency < s.Amounts[j].Currency })
+
+	return s
+}
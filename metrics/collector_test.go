@@ -0,0 +1,172 @@
+package metrics_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/metrics"
+	"thunes-client/pkg"
+	"thunes-client/thunestest"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCollectorWithClient(t *testing.T) {
+	srv := thunestest.NewServer()
+	defer srv.Close()
+	collector := metrics.NewCollector()
+	retries := api.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	tc := srv.Client(api.WithRetryPolicy(retries), api.WithMetrics(collector))
+	ctx := context.Background()
+
+	srv.InjectFault(thunestest.Fault{Method: http.MethodGet, Path: "/v2/money-transfer/balances", StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if _, err := tc.AllBalances(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.GetQuotationByID(ctx, 42); err == nil {
+		t.Fatal("GetQuotationByID() of an unknown quotation succeeded")
+	}
+	_, err := tc.SendMoney(ctx, &api.PayoutRequest{
+		ExternalID:           "payout-1",
+		PayerID:              1,
+		TransactionType:      pkg.C2C,
+		SourceCurrency:       "USD",
+		SourceCountryISOCode: "USA",
+		DestinationCurrency:  "KES",
+		Mode:                 api.SourceAmount,
+		Amount:               pkg.DecimalFromInt(100),
+		Transaction: pkg.CreateTransactionRequest{
+			CreditPartyIdentifier: &pkg.CreditPartyIdentifier{MSISDN: "254700000001"},
+			Sender:                &pkg.Sender{LastName: strPtr("Doe"), FirstName: strPtr("John"), CountryISOCode: strPtr("USA")},
+			Beneficiary:           &pkg.Beneficiary{LastName: strPtr("Wanjiru"), FirstName: strPtr("Jane")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendMoney() error = %v", err)
+	}
+
+	s := collector.Snapshot()
+	requests := make(map[string]uint64)
+	for _, r := range s.Requests {
+		requests[fmt.Sprintf("%s %s %s", r.Endpoint, r.Method, r.Status)] = r.Count
+	}
+	for key, want := range map[string]uint64{
+		"balances GET 503":      1,
+		"balances GET 200":      2, // once more by SendMoney
+		"quotations GET 404":    1,
+		"quotations POST 201":   1,
+		"transactions POST 201": 1,
+		"transactions POST 200": 1, // the confirmation
+	} {
+		if requests[key] != want {
+			t.Errorf("requests %s = %d, want %d (all: %v)", key, requests[key], want, requests)
+		}
+	}
+
+	if len(s.Retries) != 1 || s.Retries[0].Endpoint != api.EndpointBalances || s.Retries[0].Count != 1 {
+		t.Errorf("Retries = %+v, want one retry of the balances", s.Retries)
+	}
+	// SendMoney first looks the transaction up by its external id
+	if got := fmt.Sprint(s.Errors); got != "[{balances 1000503 1} {quotations 1000404 1} {transactions 1000404 1}]" {
+		t.Errorf("Errors = %s, want the codes of the failed requests", got)
+	}
+
+	want := metrics.PayoutCount{TransactionType: pkg.C2C, SourceCurrency: "USD", DestinationCurrency: "KES", Count: 1}
+	if len(s.Payouts) != 1 || s.Payouts[0] != want {
+		t.Errorf("Payouts = %+v, want %+v", s.Payouts, want)
+	}
+	amounts := make(map[string]string)
+	for _, a := range s.Amounts {
+		amounts[a.Currency] = a.Sent.String() + "/" + a.Paid.String()
+	}
+	if amounts["USD"] != "100/0" || amounts["KES"] != "0/12850.00" {
+		t.Errorf("Amounts = %v, want 100 USD sent and 12850 KES paid", amounts)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	collector := metrics.NewCollector(metrics.WithBuckets(1, 0.1))
+	for _, d := range []time.Duration{30 * time.Millisecond, 100 * time.Millisecond, 2 * time.Second} {
+		collector.ObserveRequest(api.RequestMetrics{Endpoint: api.EndpointPayers, Method: http.MethodGet, StatusCode: http.StatusOK, Duration: d})
+	}
+	collector.ObserveRequest(api.RequestMetrics{Endpoint: api.EndpointPayers, Method: http.MethodGet, Err: context.DeadlineExceeded, Duration: 500 * time.Millisecond})
+
+	s := collector.Snapshot()
+	if len(s.Latencies) != 1 {
+		t.Fatalf("Latencies = %+v, want the payers", s.Latencies)
+	}
+	h := s.Latencies[0]
+	if got := fmt.Sprint(h.Buckets); got != "[{0.1 2} {1 3}]" {
+		t.Errorf("Buckets = %s, want cumulative counts by increasing bound", got)
+	}
+	if h.Count != 4 || math.Abs(h.Sum-2.63) > 1e-9 {
+		t.Errorf("Count = %d, Sum = %g, want 4 and 2.63", h.Count, h.Sum)
+	}
+	if len(s.Requests) != 2 || s.Requests[1].Status != "error" {
+		t.Errorf("Requests = %+v, want the request without response counted as an error", s.Requests)
+	}
+
+	collector.Reset()
+	if s := collector.Snapshot(); len(s.Requests) != 0 || len(s.Latencies) != 0 {
+		t.Errorf("Snapshot() after Reset = %+v", s)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	collector := metrics.NewCollector(metrics.WithNamespace("test"), metrics.WithBuckets(0.5))
+	collector.ObserveRequest(api.RequestMetrics{Endpoint: api.EndpointQuotations, Method: http.MethodPost, Attempt: 2, StatusCode: http.StatusBadRequest, Duration: 250 * time.Millisecond, ErrorCodes: []string{`1000"7`}})
+	collector.ObservePayout(api.PayoutMetrics{TransactionType: pkg.B2B, Source: pkg.NewMoney(pkg.MustParseDecimal("10.50"), "USD"), Destination: pkg.NewMoney(pkg.MustParseDecimal("590.1"), "PHP")})
+
+	w := httptest.NewRecorder()
+	collector.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %s", ct)
+	}
+
+	out := w.Body.String()
+	for _, line := range []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{endpoint="quotations",method="POST",status="400"} 1`,
+		"# TYPE test_request_duration_seconds histogram",
+		`test_request_duration_seconds_bucket{endpoint="quotations",le="0.5"} 1`,
+		`test_request_duration_seconds_bucket{endpoint="quotations",le="+Inf"} 1`,
+		`test_request_duration_seconds_sum{endpoint="quotations"} 0.25`,
+		`test_request_retries_total{endpoint="quotations"} 1`,
+		`test_request_errors_total{endpoint="quotations",code="1000\"7"} 1`,
+		`test_payouts_total{transaction_type="B2B",source_currency="USD",destination_currency="PHP"} 1`,
+		`test_payout_sent_amount_total{currency="USD"} 10.50`,
+		`test_payout_paid_amount_total{currency="PHP"} 590.1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("the output misses %s:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, `test_payout_sent_amount_total{currency="PHP"}`) {
+		t.Errorf("the output holds a zero amount:\n%s", out)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	collector := metrics.NewCollector()
+	collector.ObserveRequest(api.RequestMetrics{Endpoint: api.EndpointPing, Method: http.MethodGet, StatusCode: http.StatusOK})
+	// expvar names can be published once per process, go test -count reruns the test
+	name := fmt.Sprintf("thunes_test_%d", time.Now().UnixNano())
+	collector.Publish(name)
+
+	var s metrics.Snapshot
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &s); err != nil {
+		t.Fatalf("the expvar variable is not a snapshot: %v", err)
+	}
+	if len(s.Requests) != 1 || s.Requests[0].Endpoint != api.EndpointPing || s.Requests[0].Count != 1 {
+		t.Errorf("Requests = %+v, want the ping", s.Requests)
+	}
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.WritePrometheus(w)
+}
+
+// Publish exports the snapshots of the collector as the expvar variable name, served as JSON by
+// the /debug/vars handler of expvar. Like expvar.Publish, it panics if the name is already used.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Snapshot()
+	}))
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text exposition format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	s := c.Snapshot()
+	b := bufio.NewWriter(w)
+	name := func(metric string) string {
+		if c.namespace == "" {
+			return metric
+		}
+		return c.namespace + "_" + metric
+	}
+
+	requests := name("requests_total")
+	header(b, requests, "counter", "Attempts of requests to the Thunes API, by endpoint, method and HTTP status.")
+	for _, r := range s.Requests {
+		sample(b, requests, labels("endpoint", string(r.Endpoint), "method", r.Method, "status", r.Status), strconv.FormatUint(r.Count, 10))
+	}
+
+	latency := name("request_duration_seconds")
+	header(b, latency, "histogram", "Latency of the requests to the Thunes API, by endpoint.")
+	for _, h := range s.Latencies {
+		for _, bucket := range h.Buckets {
+			sample(b, latency+"_bucket", labels("endpoint", string(h.Endpoint), "le", formatFloat(bucket.UpperBound)), strconv.FormatUint(bucket.Count, 10))
+		}
+		sample(b, latency+"_bucket", labels("endpoint", string(h.Endpoint), "le", "+Inf"), strconv.FormatUint(h.Count, 10))
+		sample(b, latency+"_sum", labels("endpoint", string(h.Endpoint)), formatFloat(h.Sum))
+		sample(b, latency+"_count", labels("endpoint", string(h.Endpoint)), strconv.FormatUint(h.Count, 10))
+	}
+
+	retries := name("request_retries_total")
+	header(b, retries, "counter", "Retries of requests to the Thunes API, by endpoint.")
+	for _, r := range s.Retries {
+		sample(b, retries, labels("endpoint", string(r.Endpoint)), strconv.FormatUint(r.Count, 10))
+	}
+
+	errors := name("request_errors_total")
+	header(b, errors, "counter", "Thunes error codes reported by failed requests, by endpoint and code.")
+	for _, e := range s.Errors {
+		sample(b, errors, labels("endpoint", string(e.Endpoint), "code", e.Code), strconv.FormatUint(e.Count, 10))
+	}
+
+	payouts := name("payouts_total")
+	header(b, payouts, "counter", "Confirmed payouts, by transaction type, source and destination currency.")
+	for _, p := range s.Payouts {
+		sample(b, payouts, labels("transaction_type", string(p.TransactionType), "source_currency", p.SourceCurrency, "destination_currency", p.DestinationCurrency), strconv.FormatUint(p.Count, 10))
+	}
+
+	sent := name("payout_sent_amount_total")
+	header(b, sent, "counter", "Amount sent by the confirmed payouts, by source currency.")
+	for _, a := range s.Amounts {
+		if a.Sent.Sign() != 0 {
+			sample(b, sent, labels("currency", a.Currency), a.Sent.String())
+		}
+	}
+
+	paid := name("payout_paid_amount_total")
+	header(b, paid, "counter", "Amount paid out by the confirmed payouts, by destination currency.")
+	for _, a := range s.Amounts {
+		if a.Paid.Sign() != 0 {
+			sample(b, paid, labels("currency", a.Currency), a.Paid.String())
+		}
+	}
+
+	return b.Flush()
+}
+
+func header(w *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(w *bufio.Writer, name, labels, value string) {
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, value)
+}
+
+// labelEscaper escapes label values as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats alternating label names and values.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1]))
+	}
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -5,10 +5,10 @@ type Lookup struct {
 }
 
 type Balance struct {
-	ID             int    `json:"id"`
-	Currency       string `json:"currency"`
-	Balance        int64  `json:"balance"`
-	Pending        int64  `json:"pending"`
-	Available      int64  `json:"available"`
-	CreditFacility int64  `json:"credit_facility"`
+	ID             int     `json:"id"`
+	Currency       string  `json:"currency"`
+	Balance        Decimal `json:"balance"`
+	Pending        Decimal `json:"pending"`
+	Available      Decimal `json:"available"`
+	CreditFacility Decimal `json:"credit_facility"`
 }
@@ -1,5 +0,0 @@
-package pkg
-
-type Beneficiary struct{
-	
-}
\ No newline at end of file
@@ -5,76 +5,89 @@ type CreditPartyIdentifierRequestWrapper struct {
 }
 
 type CreditPartyIdentifier struct {
-	MSISDN            string `json:"msisdn,omitempty"`
-	BankAccountNumber string `json:"bank_account_number,omitempty"`
-	IBAN              string `json:"iban,omitempty"`
-	CLABE             string `json:"clabe,omitempty"`
-	CBU               string `json:"cbu,omitempty"`
-	CBUALIAS          string `json:"cbu_alias,omitempty"`
+	MSISDN            string `json:"msisdn,omitempty" pii:"true"`
+	BankAccountNumber string `json:"bank_account_number,omitempty" pii:"true"`
+	IBAN              string `json:"iban,omitempty" pii:"true"`
+	CLABE             string `json:"clabe,omitempty" pii:"true"`
+	CBU               string `json:"cbu,omitempty" pii:"true"`
+	CBUALIAS          string `json:"cbu_alias,omitempty" pii:"true"`
 	SwiftBICCode      string `json:"swift_bic_code,omitempty"`
 	BIKCode           string `json:"bik_code,omitempty"`
 	IFSCode           string `json:"ifs_code,omitempty"`
 	SortCode          string `json:"sort_code,omitempty"`
 	ABARoutingNumber  string `json:"aba_routing_number,omitempty"`
 	BSBNumber         string `json:"bsb_number,omitempty"`
-	BranchNumber      string `json:"branch_cumber,omitempty"`
+	BranchNumber      string `json:"branch_number,omitempty"`
 	RoutingCode       string `json:"routing_code,omitempty"`
 	EntityTTID        int    `json:"entity_tt_id,omitempty"`
 	AccountType       string `json:"account_type,omitempty"`
-	AccountNumber     string `json:"account_number,omitempty"`
-	Email             string `json:"email,omitempty"`
+	AccountNumber     string `json:"account_number,omitempty" pii:"true"`
+	Email             string `json:"email,omitempty" pii:"true"`
+}
+
+// CreditParty is the owner of an account, as returned by the credit party information lookup.
+// Exactly one of its fields is set: Beneficiary for C2C and B2C transactions,
+// ReceivingBusiness for C2B and B2B transactions.
+type CreditParty struct {
+	Beneficiary       *Beneficiary
+	ReceivingBusiness *ReceivingBusinessInformation
+}
+
+// IsBusiness reports whether the owner of the account is a business.
+func (c *CreditParty) IsBusiness() bool {
+	return c.ReceivingBusiness != nil
 }
 
 // ReceivingBusinessInformation represents receiving business information for a given transaction of type C2B or B2B.
 type ReceivingBusinessInformation struct {
 	RegisteredName                 *string `json:"registered_name"`
 	TradingName                    *string `json:"trading_name"`
-	Address                        *string `json:"address"`
-	PostalCode                     *string `json:"postal_code"`
+	Address                        *string `json:"address" pii:"true"`
+	PostalCode                     *string `json:"postal_code" pii:"true"`
 	City                           *string `json:"city"`
 	ProvinceState                  *string `json:"province_state"`
 	CountryIsoCode                 *string `json:"country_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
-	Msisdn                         *string `json:"msisdn"`
-	Email                          *string `json:"email"`
+	Msisdn                         *string `json:"msisdn" pii:"true"`
+	Email                          *string `json:"email" pii:"true"`
 	RegistrationNumber             *string `json:"registration_number"`
-	TaxID                          *string `json:"tax_id"`
+	TaxID                          *string `json:"tax_id" pii:"true"`
 	DateOfIncorporation            *string `json:"date_of_incorporation"` // format: https://en.wikipedia.org/wiki/ISO_8601
-	RepresentativeLastname         *string `json:"representative_lastname"`
-	RepresentativeLastname2        *string `json:"representative_lastname2"`
-	RepresentativeFirstname        *string `json:"representative_firstname"`
-	RepresentativeMiddlename       *string `json:"representative_middlename"`
-	RepresentativeNativename       *string `json:"representative_nativename"`
+	RepresentativeLastname         *string `json:"representative_lastname" pii:"true"`
+	RepresentativeLastname2        *string `json:"representative_lastname2" pii:"true"`
+	RepresentativeFirstname        *string `json:"representative_firstname" pii:"true"`
+	RepresentativeMiddlename       *string `json:"representative_middlename" pii:"true"`
+	RepresentativeNativename       *string `json:"representative_nativename" pii:"true"`
 	RepresentativeIDType           *string `json:"representative_id_type"`
 	RepresentativeIDCountryIsoCode *string `json:"representative_id_country_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_8601
-	RepresentativeIDNumber         *string `json:"representative_id_number"`
+	RepresentativeIDNumber         *string `json:"representative_id_number" pii:"true"`
 	RepresentativeIDDeliveryDate   *string `json:"representative_id_delivery_date"`   // format: https://en.wikipedia.org/wiki/ISO_8601
 	RepresentativeIDExpirationDate *string `json:"representative_id_expiration_date"` // format: https://en.wikipedia.org/wiki/ISO_8601
 }
 
 // Beneficiary represents beneficiary information for a given transaction of type C2C or B2C.
 type Beneficiary struct {
-	LastName              *string `json:"last_name"`
-	LastName2             *string `json:"last_name2"`
-	MiddleName            *string `json:"middle_name"`
-	FirstName             *string `json:"first_name"`
-	NativeName            *string `json:"native_name"`
-	NationalityCountryI   *string `json:"nationality_country_i"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
-	Code                  *string `json:"code"`
-	DateOfBirth           *string `json:"date_of_birth"`             // format: https://en.wikipedia.org/wiki/ISO_8601
-	CountryOfBirthISOCode *string `json:"country_of_birth_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
-	Gender                *string `json:"gender"`                    // enum: 'MALE', 'FEMALE'
-	Address               *string `json:"address"`
-	PostalCode            *string `json:"postal_code"`
-	City                  *string `json:"city"`
-	CountryISOCode        *string `json:"country_iso_code"`
-	MSISDN                *string `json:"msisdn"`
-	Email                 *string `json:"email"`
-	IDType                *string `json:"id_type"`
-	IDCountryISOCode      *string `json:"id_country_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
-	IDNumber              *string `json:"id_number"`
-	IDDeliveryDate        *string `json:"id_delivery_date"`   // format: https://en.wikipedia.org/wiki/ISO_8601
-	IDExpirationDate      *string `json:"id_expiration_date"` // format: https://en.wikipedia.org/wiki/ISO_8601
-	Occupation            *string `json:"occupation"`
-	BankAccountHolderName *string `json:"bank_account_holder_name"`
-	ProvinceState         *string `json:"province_state"`
+	LastName                  *string `json:"lastname" pii:"true"`
+	LastName2                 *string `json:"lastname2" pii:"true"`
+	MiddleName                *string `json:"middlename" pii:"true"`
+	FirstName                 *string `json:"firstname" pii:"true"`
+	NativeName                *string `json:"nativename" pii:"true"`
+	NationalityCountryISOCode *string `json:"nationality_country_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
+	Code                      *string `json:"code" pii:"true"`
+	DateOfBirth               *string `json:"date_of_birth" pii:"true"`  // format: https://en.wikipedia.org/wiki/ISO_8601
+	CountryOfBirthISOCode     *string `json:"country_of_birth_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
+	Gender                    *Gender `json:"gender"`
+	Address                   *string `json:"address" pii:"true"`
+	PostalCode                *string `json:"postal_code" pii:"true"`
+	City                      *string `json:"city"`
+	CountryISOCode            *string `json:"country_iso_code"`
+	MSISDN                    *string `json:"msisdn" pii:"true"`
+	Email                     *string `json:"email" pii:"true"`
+	IDType                    *string `json:"id_type"`
+	IDCountryISOCode          *string `json:"id_country_iso_code"` // format: https://en.wikipedia.org/wiki/ISO_3166-1_alpha-3
+	IDNumber                  *string `json:"id_number" pii:"true"`
+	IDDeliveryDate            *string `json:"id_delivery_date"`   // format: https://en.wikipedia.org/wiki/ISO_8601
+	IDExpirationDate          *string `json:"id_expiration_date"` // format: https://en.wikipedia.org/wiki/ISO_8601
+	Occupation                *string `json:"occupation"`
+	BankAccountHolderName     *string `json:"bank_account_holder_name" pii:"true"`
+	ProvinceState             *string `json:"province_state"`
 }
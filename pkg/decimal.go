@@ -0,0 +1,346 @@
+package pkg
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, used for amounts and rates.
+//
+// A Decimal is immutable, operations return a new value. The zero value is 0.
+// Decimals keep the number of digits they were given after the point, so "150000.00"
+// decodes and encodes back as "150000.00"; use Cmp or Equal rather than == to compare them.
+//
+// Decimals decode from JSON numbers as well as from JSON strings, as Thunes sends both,
+// and encode back the same way.
+type Decimal struct {
+	coef   *big.Int // unscaled value, nil means zero
+	scale  int32    // number of digits after the point, never negative
+	quoted bool     // whether the JSON representation is a string
+}
+
+var bigTen = big.NewInt(10)
+
+// maxParsedScale bounds the exponent and the number of digits after the point of the decimals parsed,
+// either way, so that untrusted input cannot make the rescaling of a decimal arbitrarily expensive.
+const maxParsedScale = 64
+
+// NewDecimal returns the decimal unscaled * 10^-scale, e.g. NewDecimal(1050, 2) is 10.50.
+// A negative scale multiplies unscaled by a power of ten.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	coef := big.NewInt(unscaled)
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// DecimalFromInt returns the decimal value of i.
+func DecimalFromInt(i int64) Decimal {
+	return NewDecimal(i, 0)
+}
+
+// ParseDecimal parses a decimal number such as "12", "-0.50" or "1.5e3".
+// Exponents and numbers of digits after the point beyond 64, either way, are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := fmt.Errorf("pkg: invalid decimal %q", s)
+
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		if exponent, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil || exponent < -maxParsedScale || exponent > maxParsedScale {
+			return Decimal{}, invalid
+		}
+	}
+
+	digits := mantissa
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, invalid
+	}
+
+	coef, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, invalid
+	}
+	if mantissa[0] == '-' {
+		coef.Neg(coef)
+	}
+
+	scale := int64(len(fracPart)) - exponent
+	if scale < -maxParsedScale || scale > maxParsedScale {
+		return Decimal{}, invalid
+	}
+	if scale < 0 {
+		coef.Mul(coef, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a valid decimal.
+// It is meant for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale returns the unscaled value of d with the given number of digits after the point,
+// which must not be lower than d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	coef := new(big.Int).Set(d.int())
+	if scale > d.scale {
+		coef.Mul(coef, pow10(scale-d.scale))
+	}
+	return coef
+}
+
+// Scale returns the number of digits after the point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// String returns d in plain notation, keeping its digits after the point, e.g. "10.50".
+func (d Decimal) String() string {
+	coef := d.int()
+	digits := new(big.Int).Abs(coef).String()
+
+	sign := ""
+	if coef.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Float64 returns the nearest float64 value of d, for display or statistics only.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and e and returns -1, 0 or +1. Digits after the point do not matter: 1.5 equals 1.50.
+func (d Decimal) Cmp(e Decimal) int {
+	scale := maxScale(d, e)
+	return d.rescale(scale).Cmp(e.rescale(scale))
+}
+
+// Equal reports whether d and e have the same value.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	scale := maxScale(d, e)
+	sum := d.rescale(scale)
+	sum.Add(sum, e.rescale(scale))
+	return Decimal{coef: sum, scale: scale}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return d.Add(e.Neg())
+}
+
+// Mul returns d * e, exactly.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), e.int()), scale: d.scale + e.scale}
+}
+
+// Div returns d / e rounded half away from zero to places digits after the point, at least 0.
+// It panics if e is 0.
+func (d Decimal) Div(e Decimal, places int32) Decimal {
+	return d.divide(e, places, divRound)
+}
+
+// DivTruncate returns d / e rounded toward zero to places digits after the point, at least 0,
+// e.g. the number of whole increments in an amount with 0 places. It panics if e is 0.
+func (d Decimal) DivTruncate(e Decimal, places int32) Decimal {
+	return d.divide(e, places, func(num, den *big.Int) *big.Int {
+		return new(big.Int).Quo(num, den)
+	})
+}
+
+func (d Decimal) divide(e Decimal, places int32, div func(num, den *big.Int) *big.Int) Decimal {
+	if e.IsZero() {
+		panic("pkg: decimal division by zero")
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	// d/e * 10^places = d.coef * 10^(e.scale+places) / (e.coef * 10^d.scale)
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(e.int())
+	if exp := e.scale + places; exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+	den.Mul(den, pow10(d.scale))
+
+	return Decimal{coef: div(num, den), scale: places}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale, quoted: d.quoted}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale, quoted: d.quoted}
+}
+
+// Round returns d rounded half away from zero to places digits after the point.
+// The result always has exactly places digits after the point, e.g. 1.5 rounded to 2 places is 1.50.
+func (d Decimal) Round(places int32) Decimal {
+	return d.roundTo(places, divRound)
+}
+
+// Truncate returns d rounded toward zero to places digits after the point.
+// Like Round, the result always has exactly places digits after the point.
+func (d Decimal) Truncate(places int32) Decimal {
+	return d.roundTo(places, func(num, den *big.Int) *big.Int {
+		return new(big.Int).Quo(num, den)
+	})
+}
+
+func (d Decimal) roundTo(places int32, div func(num, den *big.Int) *big.Int) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return Decimal{coef: d.rescale(places), scale: places, quoted: d.quoted}
+	}
+	return Decimal{coef: div(d.int(), pow10(d.scale-places)), scale: places, quoted: d.quoted}
+}
+
+// Quoted returns d encoded as a JSON string rather than a JSON number.
+func (d Decimal) Quoted() Decimal {
+	d.quoted = true
+	return d
+}
+
+// Unquoted returns d encoded as a JSON number, whatever the form it was decoded from.
+func (d Decimal) Unquoted() Decimal {
+	d.quoted = false
+	return d
+}
+
+// unquoted returns a copy of *d encoded as a JSON number, nil if d is nil.
+func unquoted(d *Decimal) *Decimal {
+	if d == nil {
+		return nil
+	}
+	u := d.Unquoted()
+	return &u
+}
+
+// MarshalJSON encodes d as a JSON number, or as a JSON string if it was decoded from one.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.quoted {
+		return []byte(`"` + d.String() + `"`), nil
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or a JSON string holding a number. null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	quoted := len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"'
+	if quoted {
+		s = s[1 : len(s)-1]
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	parsed.quoted = quoted
+	*d = parsed
+	return nil
+}
+
+func maxScale(d, e Decimal) int32 {
+	if d.scale > e.scale {
+		return d.scale
+	}
+	return e.scale
+}
+
+// divRound returns num / den rounded half away from zero.
+func divRound(num, den *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// round away from zero when the remainder is at least half of the divisor
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
@@ -0,0 +1,215 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "12", want: "12"},
+		{in: "-0.50", want: "-0.50"},
+		{in: "+3.1", want: "3.1"},
+		{in: ".5", want: "0.5"},
+		{in: "5.", want: "5"},
+		{in: "150000.00", want: "150000.00"},
+		{in: "1.5e3", want: "1500"},
+		{in: "1.5E-3", want: "0.0015"},
+		{in: "-2e0", want: "-2"},
+		{in: "1e64", want: "1" + zeros(64)},
+		{in: "1e-64", want: "0." + zeros(63) + "1"},
+		{in: "0." + zeros(64), want: "0." + zeros(64)},
+		{in: "", err: true},
+		{in: "-", err: true},
+		{in: ".", err: true},
+		{in: "1.2.3", err: true},
+		{in: "abc", err: true},
+		{in: "1,5", err: true},
+		{in: "1e", err: true},
+		{in: "1e65", err: true},
+		{in: "1e-65", err: true},
+		{in: "1e2000000000", err: true},
+		{in: "1e-2000000000", err: true},
+		{in: "1e99999999999", err: true},
+		{in: "0." + zeros(65), err: true},
+		{in: "0." + zeros(10) + "e-60", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in       string
+		places   int32
+		round    string
+		truncate string
+	}{
+		{in: "1.005", places: 2, round: "1.01", truncate: "1.00"},
+		{in: "1.004", places: 2, round: "1.00", truncate: "1.00"},
+		{in: "-1.005", places: 2, round: "-1.01", truncate: "-1.00"},
+		{in: "2.5", places: 0, round: "3", truncate: "2"},
+		{in: "-2.5", places: 0, round: "-3", truncate: "-2"},
+		{in: "1.5", places: 2, round: "1.50", truncate: "1.50"},
+		{in: "0.999", places: 2, round: "1.00", truncate: "0.99"},
+		{in: "12", places: -1, round: "12", truncate: "12"},
+	}
+
+	for _, tt := range tests {
+		d := MustParseDecimal(tt.in)
+		if got := d.Round(tt.places); got.String() != tt.round {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.places, got, tt.round)
+		}
+		if got := d.Truncate(tt.places); got.String() != tt.truncate {
+			t.Errorf("%s.Truncate(%d) = %s, want %s", tt.in, tt.places, got, tt.truncate)
+		}
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	tests := []struct {
+		d, e   string
+		places int32
+		want   string
+	}{
+		{d: "10", e: "3", places: 2, want: "3.33"},
+		{d: "20", e: "3", places: 2, want: "6.67"},
+		{d: "-20", e: "3", places: 2, want: "-6.67"},
+		{d: "1", e: "8", places: 2, want: "0.13"},
+		{d: "1.50", e: "0.5", places: 0, want: "3"},
+		{d: "150000.00", e: "128.5", places: 4, want: "1167.3152"},
+		{d: "1", e: "-4", places: 1, want: "-0.3"},
+		{d: "123", e: "10", places: -1, want: "12"},
+	}
+
+	for _, tt := range tests {
+		got := MustParseDecimal(tt.d).Div(MustParseDecimal(tt.e), tt.places)
+		if got.String() != tt.want {
+			t.Errorf("%s.Div(%s, %d) = %s, want %s", tt.d, tt.e, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalDivTruncate(t *testing.T) {
+	tests := []struct {
+		d, e   string
+		places int32
+		want   string
+	}{
+		{d: "14.99", e: "5", places: 0, want: "2"},
+		{d: "20", e: "3", places: 2, want: "6.66"},
+		{d: "-20", e: "3", places: 2, want: "-6.66"},
+		{d: "0.999", e: "0.001", places: 0, want: "999"},
+	}
+
+	for _, tt := range tests {
+		got := MustParseDecimal(tt.d).DivTruncate(MustParseDecimal(tt.e), tt.places)
+		if got.String() != tt.want {
+			t.Errorf("%s.DivTruncate(%s, %d) = %s, want %s", tt.d, tt.e, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalDivByZero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Div by zero did not panic")
+		}
+	}()
+	DecimalFromInt(1).Div(Decimal{}, 2)
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: `150000.00`, out: `150000.00`},
+		{in: `"150000.00"`, out: `"150000.00"`},
+		{in: `1.5e3`, out: `1500`},
+		{in: `"-0.5"`, out: `"-0.5"`},
+	}
+
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		out, err := json.Marshal(d)
+		if err != nil {
+			t.Errorf("Marshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("round trip of %s = %s, want %s", tt.in, out, tt.out)
+		}
+	}
+
+	var d Decimal
+	if err := json.Unmarshal([]byte(`"1e1000000"`), &d); err == nil {
+		t.Error("Unmarshal of a huge exponent succeeded")
+	}
+}
+
+func TestRequestDecimalsEncodeAsNumbers(t *testing.T) {
+	var amount, fee Decimal
+	if err := json.Unmarshal([]byte(`"100.50"`), &amount); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`"1.5"`), &fee); err != nil {
+		t.Fatal(err)
+	}
+
+	quotation, err := json.Marshal(CreateQuotationRequest{Source: Source{Amount: &amount}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(quotation), `"amount":100.50`) {
+		t.Errorf("quotation request = %s, want the amount as a number", quotation)
+	}
+
+	transaction, err := json.Marshal(CreateTransactionRequest{RetailFee: &fee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(transaction), `"retail_fee":1.5`) {
+		t.Errorf("transaction request = %s, want the retail fee as a number", transaction)
+	}
+
+	// the decoded values keep their form
+	if out, _ := json.Marshal(amount); string(out) != `"100.50"` {
+		t.Errorf("amount = %s, want it quoted as decoded", out)
+	}
+	if out, _ := json.Marshal(amount.Unquoted()); string(out) != `100.50` {
+		t.Errorf("Unquoted() = %s, want a number", out)
+	}
+}
+
+func zeros(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = '0'
+	}
+	return string(b)
+}
@@ -0,0 +1,267 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidEnum is wrapped by the errors reporting a value outside of the set of values of an enum,
+// e.g. an unknown transaction type.
+var ErrInvalidEnum = errors.New("pkg: invalid value")
+
+func invalidEnum(name, value string) error {
+	return fmt.Errorf("%w for %s: %q", ErrInvalidEnum, name, value)
+}
+
+// unmarshalEnum decodes a JSON string and passes it to set.
+func unmarshalEnum(data []byte, set func(string) error) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return set(s)
+}
+
+// TransactionType identifies the kind of parties of a transaction: consumer or business, on each side.
+type TransactionType string
+
+const (
+	C2C = TransactionType("C2C") // consumer to consumer
+	C2B = TransactionType("C2B") // consumer to business
+	B2C = TransactionType("B2C") // business to consumer
+	B2B = TransactionType("B2B") // business to business
+)
+
+// ParseTransactionType parses a transaction type, ignoring case.
+func ParseTransactionType(s string) (TransactionType, error) {
+	t := TransactionType(strings.ToUpper(s))
+	if err := t.Validate(); err != nil {
+		return "", invalidEnum("transaction type", s)
+	}
+	return t, nil
+}
+
+func (t TransactionType) String() string {
+	return string(t)
+}
+
+// Validate reports an error wrapping ErrInvalidEnum if t is not a known transaction type.
+func (t TransactionType) Validate() error {
+	switch t {
+	case C2C, C2B, B2C, B2B:
+		return nil
+	default:
+		return invalidEnum("transaction type", string(t))
+	}
+}
+
+// IsBusinessSender reports whether the sending party is a business, B2C and B2B.
+func (t TransactionType) IsBusinessSender() bool {
+	return t == B2C || t == B2B
+}
+
+// IsBusinessReceiver reports whether the receiving party is a business, C2B and B2B.
+func (t TransactionType) IsBusinessReceiver() bool {
+	return t == C2B || t == B2B
+}
+
+func (t TransactionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(t))
+}
+
+// UnmarshalJSON decodes any transaction type, the ones unknown to this package included,
+// so that a type added by Thunes does not fail the decoding of a payer. Use Validate to check it.
+func (t *TransactionType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, func(s string) error {
+		*t = TransactionType(strings.ToUpper(s))
+		return nil
+	})
+}
+
+// QuotationMode selects which side of a quotation has a fixed amount.
+type QuotationMode string
+
+const (
+	// SourceAmount fixes the amount sent, the amount received is computed.
+	SourceAmount = QuotationMode("SOURCE_AMOUNT")
+	// DestinationAmount fixes the amount received, the amount sent is computed.
+	DestinationAmount = QuotationMode("DESTINATION_AMOUNT")
+)
+
+// ParseQuotationMode parses a quotation mode, ignoring case.
+func ParseQuotationMode(s string) (QuotationMode, error) {
+	m := QuotationMode(strings.ToUpper(s))
+	if err := m.Validate(); err != nil {
+		return "", invalidEnum("quotation mode", s)
+	}
+	return m, nil
+}
+
+func (m QuotationMode) String() string {
+	return string(m)
+}
+
+// Validate reports an error wrapping ErrInvalidEnum if m is not a known quotation mode.
+func (m QuotationMode) Validate() error {
+	switch m {
+	case SourceAmount, DestinationAmount:
+		return nil
+	default:
+		return invalidEnum("quotation mode", string(m))
+	}
+}
+
+func (m QuotationMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(m))
+}
+
+// UnmarshalJSON decodes any quotation mode, the ones unknown to this package included,
+// so that a mode added by Thunes does not fail the decoding of a quotation. Use Validate to check it.
+func (m *QuotationMode) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, func(s string) error {
+		*m = QuotationMode(strings.ToUpper(s))
+		return nil
+	})
+}
+
+// Gender is the gender of a sender or a beneficiary.
+type Gender string
+
+const (
+	Male   = Gender("MALE")
+	Female = Gender("FEMALE")
+)
+
+// ParseGender parses a gender, ignoring case.
+func ParseGender(s string) (Gender, error) {
+	g := Gender(strings.ToUpper(s))
+	if err := g.Validate(); err != nil {
+		return "", invalidEnum("gender", s)
+	}
+	return g, nil
+}
+
+func (g Gender) String() string {
+	return string(g)
+}
+
+// Validate reports an error wrapping ErrInvalidEnum if g is not a known gender.
+func (g Gender) Validate() error {
+	switch g {
+	case Male, Female:
+		return nil
+	default:
+		return invalidEnum("gender", string(g))
+	}
+}
+
+func (g Gender) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(g))
+}
+
+// UnmarshalJSON decodes any gender, the ones unknown to this package included,
+// so that a gender added by Thunes does not fail the decoding of a transaction. Use Validate to check it.
+func (g *Gender) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, func(s string) error {
+		*g = Gender(strings.ToUpper(s))
+		return nil
+	})
+}
+
+// AccountStatus is the result of a credit party verification.
+type AccountStatus string
+
+const (
+	// AccountAvailable means the account is available and can receive a transfer.
+	AccountAvailable = AccountStatus("AVAILABLE")
+	// AccountUnregistered means the account is not registered but can still receive a transfer.
+	AccountUnregistered = AccountStatus("UNREGISTERED")
+	// AccountUnavailable means the account is not available and will not receive a transfer.
+	AccountUnavailable = AccountStatus("UNAVAILABLE")
+	// AccountBeneficiaryMismatch means the account does not match the beneficiary details.
+	AccountBeneficiaryMismatch = AccountStatus("UNAVAILABLE-BENEFICIARY-MISMATCH")
+	// AccountInvalid means the account number is invalid.
+	AccountInvalid = AccountStatus("UNAVAILABLE-INVALID-ACCOUNT")
+	// AccountBarred means the account number is barred.
+	AccountBarred = AccountStatus("UNAVAILABLE-BARRED-ACCOUNT")
+)
+
+// ParseAccountStatus parses an account status, ignoring case.
+func ParseAccountStatus(s string) (AccountStatus, error) {
+	a := AccountStatus(strings.ToUpper(s))
+	if err := a.Validate(); err != nil {
+		return "", invalidEnum("account status", s)
+	}
+	return a, nil
+}
+
+func (a AccountStatus) String() string {
+	return string(a)
+}
+
+// Validate reports an error wrapping ErrInvalidEnum if a is not a known account status.
+func (a AccountStatus) Validate() error {
+	switch a {
+	case AccountAvailable, AccountUnregistered, AccountUnavailable, AccountBeneficiaryMismatch, AccountInvalid, AccountBarred:
+		return nil
+	default:
+		return invalidEnum("account status", string(a))
+	}
+}
+
+// CanReceive reports whether the account can receive a transfer.
+// The UNAVAILABLE statuses, including the ones unknown to this package, cannot.
+func (a AccountStatus) CanReceive() bool {
+	return a == AccountAvailable || a == AccountUnregistered
+}
+
+func (a AccountStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(a))
+}
+
+// UnmarshalJSON decodes any account status, the ones unknown to this package included,
+// so that a status added by Thunes does not fail the verification. Use Validate to check it.
+func (a *AccountStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, func(s string) error {
+		*a = AccountStatus(strings.ToUpper(s))
+		return nil
+	})
+}
+
+// ParseTransactionAttachmentType parses an attachment type, ignoring case.
+func ParseTransactionAttachmentType(s string) (TransactionAttachmentType, error) {
+	t := TransactionAttachmentType(strings.ToLower(s))
+	if err := t.Validate(); err != nil {
+		return "", invalidEnum("attachment type", s)
+	}
+	return t, nil
+}
+
+func (t TransactionAttachmentType) String() string {
+	return string(t)
+}
+
+// Validate reports an error wrapping ErrInvalidEnum if t is not a known attachment type.
+func (t TransactionAttachmentType) Validate() error {
+	switch t {
+	case INVOICE, PURCHASE_ORDER, DELIVERY_SLIP, CONTRACT:
+		return nil
+	default:
+		return invalidEnum("attachment type", string(t))
+	}
+}
+
+func (t TransactionAttachmentType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(t))
+}
+
+// UnmarshalJSON decodes any attachment type, the ones unknown to this package included,
+// so that a type added by Thunes does not fail the decoding of the attachments. Use Validate to check it.
+func (t *TransactionAttachmentType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, func(s string) error {
+		*t = TransactionAttachmentType(strings.ToLower(s))
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdentifier is wrapped by the errors of the credit party identifier validators.
+var ErrInvalidIdentifier = errors.New("pkg: invalid identifier")
+
+var (
+	msisdnPattern   = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+	ibanPattern     = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern      = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	ifscPattern     = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	sortCodePattern = regexp.MustCompile(`^[0-9]{2}-?[0-9]{2}-?[0-9]{2}$`)
+	bsbPattern      = regexp.MustCompile(`^[0-9]{3}-?[0-9]{3}$`)
+	digitsPattern   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// identifierError is returned by the validators, it matches ErrInvalidIdentifier.
+type identifierError struct {
+	name   string
+	reason string
+}
+
+func invalidIdentifier(name, reason string) error {
+	return &identifierError{name: name, reason: reason}
+}
+
+func (e *identifierError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalidIdentifier, e.name, e.reason)
+}
+
+func (e *identifierError) Is(target error) bool {
+	return target == ErrInvalidIdentifier
+}
+
+// ValidateMSISDN checks that s is a phone number in the E.164 format, with or without the leading "+".
+func ValidateMSISDN(s string) error {
+	if !msisdnPattern.MatchString(s) {
+		return invalidIdentifier("MSISDN", "must be an international number of at most 15 digits")
+	}
+	return nil
+}
+
+// ValidateIBAN checks the format and the mod-97 check digits of an IBAN. Spaces are ignored.
+func ValidateIBAN(s string) error {
+	iban := strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	if !ibanPattern.MatchString(iban) {
+		return invalidIdentifier("IBAN", "must be a country code, 2 check digits and up to 30 letters or digits")
+	}
+
+	// move the country code and the check digits to the end and replace the letters by 10 to 35
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if n.Mod(n, big.NewInt(97)).Int64() != 1 {
+		return invalidIdentifier("IBAN", "has invalid check digits")
+	}
+	return nil
+}
+
+// ValidateCLABE checks the length and the check digit of a Mexican CLABE.
+func ValidateCLABE(s string) error {
+	if len(s) != 18 || !digitsPattern.MatchString(s) {
+		return invalidIdentifier("CLABE", "must be 18 digits")
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(s[i]-'0') * weights[i%3] % 10
+	}
+	if (10-sum%10)%10 != int(s[17]-'0') {
+		return invalidIdentifier("CLABE", "has an invalid check digit")
+	}
+	return nil
+}
+
+// ValidateCBU checks the length and the check digits of the two blocks of an Argentinian CBU.
+func ValidateCBU(s string) error {
+	if len(s) != 22 || !digitsPattern.MatchString(s) {
+		return invalidIdentifier("CBU", "must be 22 digits")
+	}
+	if !cbuBlockValid(s[:8], []int{7, 1, 3, 9, 7, 1, 3}) || !cbuBlockValid(s[8:], []int{3, 9, 7, 1, 3, 9, 7, 1, 3, 9, 7, 1, 3}) {
+		return invalidIdentifier("CBU", "has an invalid check digit")
+	}
+	return nil
+}
+
+// cbuBlockValid reports whether the last digit of the block is the check digit of the others.
+func cbuBlockValid(block string, weights []int) bool {
+	sum := 0
+	for i, w := range weights {
+		sum += int(block[i]-'0') * w
+	}
+	return (10-sum%10)%10 == int(block[len(weights)]-'0')
+}
+
+// ValidateABARoutingNumber checks the length and the checksum of an American ABA routing number.
+func ValidateABARoutingNumber(s string) error {
+	if len(s) != 9 || !digitsPattern.MatchString(s) {
+		return invalidIdentifier("ABA routing number", "must be 9 digits")
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(s[i]-'0') * weights[i%3]
+	}
+	if sum%10 != 0 {
+		return invalidIdentifier("ABA routing number", "has an invalid checksum")
+	}
+	return nil
+}
+
+// ValidateBIC checks the format of a SWIFT BIC code, of 8 or 11 characters.
+func ValidateBIC(s string) error {
+	if !bicPattern.MatchString(strings.ToUpper(s)) {
+		return invalidIdentifier("SWIFT BIC code", "must be 8 or 11 letters or digits, e.g. BNORPHMM")
+	}
+	return nil
+}
+
+// ValidateIFSC checks the format of an Indian IFS code: 4 letters, a 0 and 6 letters or digits.
+func ValidateIFSC(s string) error {
+	if !ifscPattern.MatchString(strings.ToUpper(s)) {
+		return invalidIdentifier("IFS code", "must be 4 letters, a 0 and 6 letters or digits, e.g. SBIN0005943")
+	}
+	return nil
+}
+
+// ValidateSortCode checks the format of a British sort code: 6 digits, optionally grouped by 2 with dashes.
+func ValidateSortCode(s string) error {
+	if !sortCodePattern.MatchString(s) {
+		return invalidIdentifier("sort code", "must be 6 digits, e.g. 12-34-56")
+	}
+	return nil
+}
+
+// ValidateBSBNumber checks the format of an Australian BSB number: 6 digits, optionally grouped by 3 with a dash.
+func ValidateBSBNumber(s string) error {
+	if !bsbPattern.MatchString(s) {
+		return invalidIdentifier("BSB number", "must be 6 digits, e.g. 062-000")
+	}
+	return nil
+}
+
+// identifierValidators holds the format validator of the fields of CreditPartyIdentifier, keyed by their JSON name.
+var identifierValidators = map[string]func(string) error{
+	"msisdn":             ValidateMSISDN,
+	"iban":               ValidateIBAN,
+	"clabe":              ValidateCLABE,
+	"cbu":                ValidateCBU,
+	"aba_routing_number": ValidateABARoutingNumber,
+	"swift_bic_code":     ValidateBIC,
+	"ifs_code":           ValidateIFSC,
+	"sort_code":          ValidateSortCode,
+	"bsb_number":         ValidateBSBNumber,
+}
+
+// Validate checks the format of every field of the identifier which is set and has a local validator,
+// so that bad bank details are caught before they are sent. It returns a *ValidationError listing
+// every invalid field, or an empty identifier. Whether the combination of fields is accepted by
+// a payer is checked by Validate.
+func (id CreditPartyIdentifier) Validate() error {
+	verr := &ValidationError{}
+	if id == (CreditPartyIdentifier{}) {
+		verr.add("credit_party_identifier", "is mandatory")
+		return verr
+	}
+
+	validateIdentifierFormats(verr, &id)
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateIdentifierFormats adds an error to verr for every field of the identifier with an invalid format.
+func validateIdentifierFormats(verr *ValidationError, id *CreditPartyIdentifier) {
+	v := reflect.ValueOf(id).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		validate, ok := identifierValidators[name]
+		if !ok || v.Field(i).String() == "" {
+			continue
+		}
+		if err := validate(v.Field(i).String()); err != nil {
+			verr.add("credit_party_identifier."+name, "%s", err.(*identifierError).reason)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIdentifierChecksums(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		valid    []string
+		invalid  []string
+	}{
+		{
+			name:     "IBAN",
+			validate: ValidateIBAN,
+			valid:    []string{"GB82WEST12345698765432", "GB82 WEST 1234 5698 7654 32", "de89370400440532013000", "FR1420041010050500013M02606"},
+			invalid:  []string{"GB82WEST12345698765433", "GB28WEST12345698765432", "DE8937040044053201300", "GB82-WEST-1234", "1282WEST12345698765432", ""},
+		},
+		{
+			name:     "CLABE",
+			validate: ValidateCLABE,
+			valid:    []string{"032180000118359719", "002010077777777771"},
+			invalid:  []string{"032180000118359718", "002010077777777777", "03218000011835971", "03218000011835971A", ""},
+		},
+		{
+			name:     "CBU",
+			validate: ValidateCBU,
+			valid:    []string{"2850590940090418135201"},
+			invalid:  []string{"2850590840090418135201", "2850590940090418135202", "285059094009041813520", "28505909400904181352O1", ""},
+		},
+		{
+			name:     "ABA routing number",
+			validate: ValidateABARoutingNumber,
+			valid:    []string{"021000021", "011000015", "122105155"},
+			invalid:  []string{"021000022", "123456789", "02100002", "0210000211", "02100002A", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, s := range tt.valid {
+			if err := tt.validate(s); err != nil {
+				t.Errorf("%s %q: error = %v, want valid", tt.name, s, err)
+			}
+		}
+		for _, s := range tt.invalid {
+			if err := tt.validate(s); !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("%s %q: error = %v, want ErrInvalidIdentifier", tt.name, s, err)
+			}
+		}
+	}
+}
+
+func TestCreditPartyIdentifierValidate(t *testing.T) {
+	if err := (CreditPartyIdentifier{IBAN: "GB82WEST12345698765432", SwiftBICCode: "NWBKGB2L"}).Validate(); err != nil {
+		t.Errorf("Validate() of a valid identifier error = %v", err)
+	}
+
+	err := CreditPartyIdentifier{IBAN: "GB82WEST12345698765433", ABARoutingNumber: "021000022"}.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 2 {
+		t.Fatalf("Validate() error = %v, want the 2 invalid fields", err)
+	}
+	if verr.Errors[0].Field != "credit_party_identifier.iban" || verr.Errors[1].Field != "credit_party_identifier.aba_routing_number" {
+		t.Errorf("fields = %s and %s, want iban and aba_routing_number", verr.Errors[0].Field, verr.Errors[1].Field)
+	}
+
+	if err := (CreditPartyIdentifier{}).Validate(); err == nil {
+		t.Error("Validate() of an empty identifier succeeded")
+	}
+}
@@ -1,21 +1,22 @@
 package pkg
 
 type Payer struct {
-	ID                   int                    `json:"id"`
-	Name                 string                 `json:"name"`
-	Precision            int                    `json:"precision,omitempty"`
-	Increment            float32                `json:"increment,omitempty"`
-	Currency             string                 `json:"currency"`
-	CountryISOCode       string                 `json:"country_iso_code"`
-	MinTransactionAmount int                    `json:"minimum_transaction_amount,omitempty"`
-	MaxTransactionAmount int                    `json:"maximum_transaction_amount,omitempty"`
-	Service              Service                `json:"service"`
-	TransactionTypes     map[string]interface{} `json:"transaction_types,omitempty"`
+	ID                   int                                           `json:"id"`
+	Name                 string                                        `json:"name"`
+	Precision            int                                           `json:"precision,omitempty"`
+	Increment            Decimal                                       `json:"increment"`
+	Currency             string                                        `json:"currency"`
+	CountryISOCode       string                                        `json:"country_iso_code"`
+	MinTransactionAmount Decimal                                       `json:"minimum_transaction_amount"`
+	MaxTransactionAmount Decimal                                       `json:"maximum_transaction_amount"`
+	Service              Service                                       `json:"service"`
+	TransactionTypes     map[TransactionType]BaseTransactionInfomation `json:"transaction_types,omitempty"`
 }
 
+// BaseTransactionInfomation describes the limits and the requirements of a payer for a transaction type.
 type BaseTransactionInfomation struct {
-	MinTransactionAmount            string                  `json:"minimum_transaction_amount"`
-	MaxTransactionAmount            string                  `json:"maximum_transaction_amount"`
+	MinTransactionAmount            Decimal                 `json:"minimum_transaction_amount"`
+	MaxTransactionAmount            Decimal                 `json:"maximum_transaction_amount"`
 	CreditPartyIndentifiersAccepted [][]string              `json:"credit_party_identifiers_accepted"`
 	RequiredSendingIdentityFields   [][]string              `json:"required_sending_entity_fields"`
 	RequiredReceivingIdentityFields [][]string              `json:"required_receiving_entity_fields"`
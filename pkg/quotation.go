@@ -1,36 +1,75 @@
 package pkg
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type CreateQuotationRequest struct {
-	ExternalID      string         `json:"external_id"`
-	PayerID         string         `json:"payer_id"`
-	Mode            string         `json:"mode"`
-	TransactionType string         `json:"transaction_type"`
-	Source          Source         `json:"source"`
-	Destination     CurrencyAmount `json:"destination"`
+	ExternalID      string          `json:"external_id"`
+	PayerID         string          `json:"payer_id"`
+	Mode            QuotationMode   `json:"mode"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Source          Source          `json:"source"`
+	Destination     CurrencyAmount  `json:"destination"`
+}
+
+// MarshalJSON encodes the amounts of the request as JSON numbers, as the API documents them,
+// even when they were decoded from JSON strings, e.g. from a payout file.
+func (r CreateQuotationRequest) MarshalJSON() ([]byte, error) {
+	type plain CreateQuotationRequest
+	p := plain(r)
+	p.Source.Amount = unquoted(r.Source.Amount)
+	p.Destination.Amount = unquoted(r.Destination.Amount)
+	return json.Marshal(p)
 }
 
 type Quotation struct {
-	ID              int            `json:"id"`
-	ExternalID      string         `json:"external_id"`
-	Payer           Payer          `json:"payer"`
-	Mode            string         `json:"mode"`
-	TransactionType string         `json:"transaction_type"`
-	Source          Source         `json:"Source"`
-	Destination     CurrencyAmount `json:"Destination"`
-	SentAmount      CurrencyAmount `json:"sent_amount"`
-	WholeSaleFXRate float64        `json:"wholesale_fx_rate"`
-	Fee             CurrencyAmount `json:"fee"`
-	CreationDate    string         `json:"creation_date"`
-	ExpirationDate  string         `json:"expiration_date"`
+	ID              int             `json:"id"`
+	ExternalID      string          `json:"external_id"`
+	Payer           Payer           `json:"payer"`
+	Mode            QuotationMode   `json:"mode"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Source          Source          `json:"Source"`
+	Destination     Money           `json:"Destination"`
+	SentAmount      Money           `json:"sent_amount"`
+	WholeSaleFXRate Decimal         `json:"wholesale_fx_rate"`
+	Fee             Money           `json:"fee"`
+	CreationDate    Timestamp       `json:"creation_date"`
+	ExpirationDate  Timestamp       `json:"expiration_date"`
+}
+
+// IsExpired reports whether the quotation has expired, a transaction can no longer be created from it.
+func (q *Quotation) IsExpired() bool {
+	return !time.Now().Before(q.ExpirationDate.Time)
+}
+
+// TimeLeft returns the time left before the quotation expires, 0 if it has expired.
+func (q *Quotation) TimeLeft() time.Duration {
+	if left := time.Until(q.ExpirationDate.Time); left > 0 {
+		return left
+	}
+	return 0
 }
 
 type Source struct {
-	CountryISOCode string `json:"country_iso_code"`
-	Currency       string `json:"currency"`
-	Amount         *int64 `json:"amount"`
+	CountryISOCode string   `json:"country_iso_code"`
+	Currency       string   `json:"currency"`
+	Amount         *Decimal `json:"amount"`
+}
+
+// Money returns the amount of the source, 0 if it is not set.
+func (s Source) Money() Money {
+	var m Money
+	if s.Amount != nil {
+		m.Amount = *s.Amount
+	}
+	m.Currency = s.Currency
+	return m
 }
 
+// CurrencyAmount is an amount which can be left out, as in the destination of a quotation request.
 type CurrencyAmount struct {
-	Amount   *int64 `json:"amount"`
-	Currency string `json:"currency"`
+	Amount   *Decimal `json:"amount"`
+	Currency string   `json:"currency"`
 }
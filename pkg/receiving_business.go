@@ -1,5 +0,0 @@
-package pkg
-
-type ReceivingBusinessInformation struct{
-	
-}
\ No newline at end of file
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the personal data masked by RedactJSON.
+const Redacted = "[REDACTED]"
+
+// The fields holding personal data, e.g. names, id numbers, MSISDNs and bank accounts, are tagged pii:"true".
+// piiContainers maps the JSON name under which a struct with such fields is nested, e.g. "sender",
+// to the JSON names of its personal fields. piiKeys is the union of the personal fields.
+var piiContainers, piiKeys = collectPII(
+	reflect.TypeOf(Transaction{}),
+	reflect.TypeOf(CreateTransactionRequest{}),
+	reflect.TypeOf(CreditPartyIdentifierRequestWrapper{}),
+)
+
+func collectPII(roots ...reflect.Type) (map[string]map[string]bool, map[string]bool) {
+	containers := make(map[string]map[string]bool)
+	keys := make(map[string]bool)
+	seen := make(map[reflect.Type]bool)
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		t = elemType(t)
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			if field.Tag.Get("pii") == "true" {
+				keys[name] = true
+			}
+
+			fieldType := elemType(field.Type)
+			if fieldType.Kind() != reflect.Struct {
+				continue
+			}
+			for j := 0; j < fieldType.NumField(); j++ {
+				if child := fieldType.Field(j); child.Tag.Get("pii") == "true" {
+					if containers[name] == nil {
+						containers[name] = make(map[string]bool)
+					}
+					containers[name][jsonName(child)] = true
+				}
+			}
+			walk(fieldType)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+
+	return containers, keys
+}
+
+// elemType returns the type of the values held by pointers, slices and maps of t.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonName returns the JSON name of an exported field, empty if it is not encoded.
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// RedactJSON returns a copy of a JSON document of the Thunes API whose personal data is replaced by Redacted:
+// the fields tagged pii of the senders, beneficiaries, businesses and credit party identifiers it holds,
+// and the personal fields of the document itself when it is one of them, e.g. a credit party information.
+// It returns an error if data is not JSON.
+func RedactJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	redact(doc, piiKeys)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// redact masks the personal fields of v, which are keys when v is an object or the objects of an array.
+func redact(v interface{}, keys map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if keys[key] && child != nil {
+				v[key] = Redacted
+				continue
+			}
+			redact(child, piiContainers[key])
+		}
+	case []interface{}:
+		for _, child := range v {
+			redact(child, keys)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		// masked are the personal values which must not be left, kept the values which must
+		masked []string
+		kept   []string
+	}{
+		{
+			name: "transaction",
+			in: `{"id": 42, "external_id": "payout-1", "status": "70000",
+				"credit_party_identifier": {"msisdn": "+254700000001", "iban": "GB82WEST12345698765432", "swift_bic_code": "WESTGB2L"},
+				"sender": {"lastname": "Doe", "firstname": "John", "date_of_birth": "1970-01-01", "id_number": "X1234567", "country_iso_code": "USA", "nationality_country_iso_code": "USA"},
+				"beneficiary": {"lastname": "Wanjiru", "firstname": "Jane", "address": "12 Moi Avenue", "email": "jane@example.com", "country_iso_code": "KEN"},
+				"destination": {"amount": 12950.00, "currency": "KES"}}`,
+			masked: []string{"+254700000001", "GB82WEST12345698765432", "Doe", "John", "1970-01-01", "X1234567", "Wanjiru", "Jane", "12 Moi Avenue", "jane@example.com"},
+			kept:   []string{"payout-1", "70000", "WESTGB2L", `"country_iso_code":"KEN"`, `"nationality_country_iso_code":"USA"`, "12950.00", "KES"},
+		},
+		{
+			name: "businesses",
+			in: `{"sending_business": {"registered_name": "Acme", "tax_id": "TAX-1", "representative_lastname": "Roe", "address": "1 Main St"},
+				"receiving_business": {"registered_name": "Globex", "representative_firstname": "Ann", "msisdn": "+639170000000"}}`,
+			masked: []string{"TAX-1", "Roe", "1 Main St", "Ann", "+639170000000"},
+			kept:   []string{"Acme", "Globex"},
+		},
+		{
+			name: "array of transactions",
+			in: `[{"id": 1, "sender": {"lastname": "Doe"}, "credit_party_identifier": {"bank_account_number": "0123456789"}},
+				{"id": 2, "beneficiary": {"firstname": "Jane", "bank_account_holder_name": "Jane Wanjiru"}}]`,
+			masked: []string{"Doe", "0123456789", "Jane"},
+			kept:   []string{`"id":1`, `"id":2`},
+		},
+		{
+			name:   "nested in a list",
+			in:     `{"transactions": [{"id": 1, "beneficiary": {"lastname": "Wanjiru", "code": "B-1"}}]}`,
+			masked: []string{"Wanjiru", "B-1"},
+			kept:   []string{`"id":1`},
+		},
+		{
+			name:   "credit party document",
+			in:     `{"msisdn": "+254700000001", "clabe": "032180000118359719", "cbu": "0110599520000001235579", "account_number": "42"}`,
+			masked: []string{"+254700000001", "032180000118359719", "0110599520000001235579", `"42"`},
+		},
+		{
+			name:   "credit party information",
+			in:     `{"beneficiary": {"lastname": "Wanjiru", "firstname": "Jane", "id_type": "PASSPORT"}}`,
+			masked: []string{"Wanjiru", "Jane"},
+			kept:   []string{"PASSPORT"},
+		},
+		{
+			name: "not personal",
+			in:   `{"id": 1, "name": "M-Pesa Kenya", "currency": "KES", "country_iso_code": "KEN"}`,
+			kept: []string{"M-Pesa Kenya", "KES", "KEN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := RedactJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("RedactJSON() error = %v", err)
+			}
+			if !json.Valid(out) {
+				t.Fatalf("RedactJSON() = %s, not JSON", out)
+			}
+			for _, s := range tt.masked {
+				if strings.Contains(string(out), s) {
+					t.Errorf("RedactJSON() = %s, still holds %s", out, s)
+				}
+			}
+			for _, s := range tt.kept {
+				if !strings.Contains(string(out), s) {
+					t.Errorf("RedactJSON() = %s, lost %s", out, s)
+				}
+			}
+			if len(tt.masked) > 0 && !strings.Contains(string(out), Redacted) {
+				t.Errorf("RedactJSON() = %s, want %s in place of the personal data", out, Redacted)
+			}
+		})
+	}
+}
+
+func TestRedactJSONKeepsNulls(t *testing.T) {
+	out, err := RedactJSON([]byte(`{"sender": {"lastname": null, "firstname": "John"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"sender":{"firstname":"[REDACTED]","lastname":null}}`; string(out) != want {
+		t.Errorf("RedactJSON() = %s, want %s", out, want)
+	}
+}
+
+func TestRedactJSONInvalid(t *testing.T) {
+	for _, in := range []string{``, `{"sender": {"lastname": "Do`, `<html>`} {
+		if out, err := RedactJSON([]byte(in)); err == nil {
+			t.Errorf("RedactJSON(%q) = %s, want an error", in, out)
+		}
+	}
+}
+
+func TestPIITagsCollected(t *testing.T) {
+	for _, container := range []string{"sender", "beneficiary", "sending_business", "receiving_business", "credit_party_identifier"} {
+		if len(piiContainers[container]) == 0 {
+			t.Errorf("no personal field collected for %s", container)
+		}
+	}
+	if !piiContainers["sender"]["lastname"] || !piiContainers["credit_party_identifier"]["iban"] {
+		t.Errorf("containers = %v, want the sender lastname and the identifier iban", piiContainers)
+	}
+}
@@ -2,31 +2,31 @@ package pkg
 
 // Sender represents sender information for a given transaction of type C2C or C2B.
 type Sender struct {
-	LastName                  *string `json:"lastname"`
-	LastName2                 *string `json:"lastname2"`
-	MiddleName                *string `json:"middlename"`
-	FirstName                 *string `json:"firstname"`
-	NativeName                *string `json:"nativename"`
+	LastName                  *string `json:"lastname" pii:"true"`
+	LastName2                 *string `json:"lastname2" pii:"true"`
+	MiddleName                *string `json:"middlename" pii:"true"`
+	FirstName                 *string `json:"firstname" pii:"true"`
+	NativeName                *string `json:"nativename" pii:"true"`
 	NationalityCountryIsoCode *string `json:"nationality_country_iso_code"`
-	Code                      *string `json:"code"`
-	DateOfBirth               *string `json:"date_of_birth"`
+	Code                      *string `json:"code" pii:"true"`
+	DateOfBirth               *string `json:"date_of_birth" pii:"true"`
 	CountryOfBirthISOCode     *string `json:"country_of_birth_iso_code"`
-	Gender                    *string `json:"gender"`
-	Address                   *string `json:"address"`
-	PostalCode                *string `json:"postal_code"`
+	Gender                    *Gender `json:"gender"`
+	Address                   *string `json:"address" pii:"true"`
+	PostalCode                *string `json:"postal_code" pii:"true"`
 	City                      *string `json:"city"`
 	CountryISOCode            *string `json:"country_iso_code"`
-	MSISDN                    *string `json:"msisdn"`
-	Email                     *string `json:"email"`
+	MSISDN                    *string `json:"msisdn" pii:"true"`
+	Email                     *string `json:"email" pii:"true"`
 	IDType                    *string `json:"id_type"`
 	IDCountryISOCode          *string `json:"id_country_iso_code"`
-	IDNumber                  *string `json:"id_number"`
+	IDNumber                  *string `json:"id_number" pii:"true"`
 	IDDeliveryDate            *string `json:"id_delivery_date"`
 	IDExpirationDate          *string `json:"id_expiration_date"`
 	Occupation                *string `json:"occupation"`
 	Bank                      *string `json:"bank"`
-	BankAccount               *string `json:"bank_account"`
-	Card                      *string `json:"card"`
+	BankAccount               *string `json:"bank_account" pii:"true"`
+	Card                      *string `json:"card" pii:"true"`
 	ProvinceState             *string `json:"province_state"`
 	BeneficiaryRelationship   *string `json:"beneficiary_relationship"`
 	SourceOfFunds             *string `json:"source_of_funds"`
@@ -36,25 +36,25 @@ type Sender struct {
 type SendingBusinessInformation struct {
 	RegisteredName                 *string `json:"registered_name"`
 	TradingName                    *string `json:"trading_name"`
-	Address                        *string `json:"address"`
-	PostalCode                     *string `json:"postal_code"`
+	Address                        *string `json:"address" pii:"true"`
+	PostalCode                     *string `json:"postal_code" pii:"true"`
 	City                           *string `json:"city"`
 	ProvinceState                  *string `json:"province_state"`
 	CountryISOCode                 *string `json:"country_iso_code"`
-	MSISDN                         *string `json:"msisdn"`
-	Email                          *string `json:"email"`
+	MSISDN                         *string `json:"msisdn" pii:"true"`
+	Email                          *string `json:"email" pii:"true"`
 	RegistrationNumber             *string `json:"registration_number"`
 	Code                           *string `json:"code"`
-	TaxID                          *string `json:"tax_id"`
+	TaxID                          *string `json:"tax_id" pii:"true"`
 	DateOfIncorporation            *string `json:"date_of_incorporation"`
-	RepresentativeLastName         *string `json:"representative_lastname"`
-	RepresentativeLastName2        *string `json:"representative_lastname2"`
-	RepresentativeFirstName        *string `json:"representative_firstname"`
-	RepresentativeMiddleName       *string `json:"representative_middlename"`
-	RepresentativeNativeName       *string `json:"representative_nativename"`
+	RepresentativeLastName         *string `json:"representative_lastname" pii:"true"`
+	RepresentativeLastName2        *string `json:"representative_lastname2" pii:"true"`
+	RepresentativeFirstName        *string `json:"representative_firstname" pii:"true"`
+	RepresentativeMiddleName       *string `json:"representative_middlename" pii:"true"`
+	RepresentativeNativeName       *string `json:"representative_nativename" pii:"true"`
 	RepresentativeIDType           *string `json:"representative_id_type"`
 	RepresentativeIDCountryISOCode *string `json:"representative_id_country_iso_code"`
-	RepresentativeIDNumber         *string `json:"representative_id_number"`
+	RepresentativeIDNumber         *string `json:"representative_id_number" pii:"true"`
 	RepresentativeIDDeliveryDate   *string `json:"representative_id_delivery_date"`
 	RepresentativeIDExpirationDate *string `json:"representative_id_expiration_date"`
 }
@@ -0,0 +1,111 @@
+package pkg
+
+import "fmt"
+
+// TransactionStatus is the detailed status of a transaction, e.g. "20150" for CONFIRMED-WAITING-FOR-PICKUP.
+// Its first digit is its StatusClass.
+type TransactionStatus string
+
+const (
+	StatusCreated                   = TransactionStatus("10000")
+	StatusConfirmed                 = TransactionStatus("20000")
+	StatusConfirmedUnderReview      = TransactionStatus("20110")
+	StatusConfirmedWaitingForPickup = TransactionStatus("20150")
+	StatusRejected                  = TransactionStatus("30000")
+	StatusCancelled                 = TransactionStatus("40000")
+	StatusSubmitted                 = TransactionStatus("50000")
+	StatusAvailable                 = TransactionStatus("60000")
+	StatusCompleted                 = TransactionStatus("70000")
+	StatusReversed                  = TransactionStatus("80000")
+	StatusDeclined                  = TransactionStatus("90000")
+)
+
+// Class returns the status class of the status, or an empty class if the status is malformed.
+func (s TransactionStatus) Class() StatusClass {
+	if len(s) == 0 || s[0] < '1' || s[0] > '9' {
+		return ""
+	}
+	return StatusClass(s[:1])
+}
+
+// IsFinal reports whether the transaction will not change status anymore.
+func (s TransactionStatus) IsFinal() bool {
+	return s.Class().IsFinal()
+}
+
+// StatusClass groups the transaction statuses by stage of processing.
+//
+//	1 CREATED     the transaction has been created and waits for a confirmation
+//	2 CONFIRMED   the transaction has been confirmed and is being processed
+//	3 REJECTED    the transaction has been rejected by Thunes or the payer (final)
+//	4 CANCELLED   the transaction has been cancelled (final)
+//	5 SUBMITTED   the transaction has been submitted to the payer
+//	6 AVAILABLE   the funds are available for pickup by the beneficiary
+//	7 COMPLETED   the transaction has been paid out (final)
+//	8 REVERSED    the payout has been reversed (final)
+//	9 DECLINED    the transaction has been declined by the payer (final)
+type StatusClass string
+
+const (
+	StatusClassCreated   = StatusClass("1")
+	StatusClassConfirmed = StatusClass("2")
+	StatusClassRejected  = StatusClass("3")
+	StatusClassCancelled = StatusClass("4")
+	StatusClassSubmitted = StatusClass("5")
+	StatusClassAvailable = StatusClass("6")
+	StatusClassCompleted = StatusClass("7")
+	StatusClassReversed  = StatusClass("8")
+	StatusClassDeclined  = StatusClass("9")
+)
+
+var statusClassNames = map[StatusClass]string{
+	StatusClassCreated:   "CREATED",
+	StatusClassConfirmed: "CONFIRMED",
+	StatusClassRejected:  "REJECTED",
+	StatusClassCancelled: "CANCELLED",
+	StatusClassSubmitted: "SUBMITTED",
+	StatusClassAvailable: "AVAILABLE",
+	StatusClassCompleted: "COMPLETED",
+	StatusClassReversed:  "REVERSED",
+	StatusClassDeclined:  "DECLINED",
+}
+
+// String returns the name of the status class, e.g. "COMPLETED".
+func (c StatusClass) String() string {
+	if name, ok := statusClassNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("StatusClass(%q)", string(c))
+}
+
+// IsFinal reports whether the transactions of the class will not change status anymore.
+func (c StatusClass) IsFinal() bool {
+	switch c {
+	case StatusClassRejected, StatusClassCancelled, StatusClassCompleted, StatusClassReversed, StatusClassDeclined:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFailed reports whether the transactions of the class were not paid out and never will be:
+// rejected, cancelled, reversed or declined.
+func (c StatusClass) IsFailed() bool {
+	switch c {
+	case StatusClassRejected, StatusClassCancelled, StatusClassReversed, StatusClassDeclined:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsConfirmed reports whether the transactions of the class were confirmed and did not fail since:
+// confirmed, submitted, available or completed.
+func (c StatusClass) IsConfirmed() bool {
+	switch c {
+	case StatusClassConfirmed, StatusClassSubmitted, StatusClassAvailable, StatusClassCompleted:
+		return true
+	default:
+		return false
+	}
+}
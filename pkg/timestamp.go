@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TimestampLayout is the layout of the dates and times sent by Thunes, in UTC without an offset.
+const TimestampLayout = "2006-01-02T15:04:05"
+
+// Timestamp is a date and time of the Thunes API, such as the creation and expiration dates of a quotation or a transaction.
+//
+// Thunes sends them in UTC without an offset, e.g. "2018-02-27T08:25:31", which time.Time cannot decode.
+// Timestamps decode from that layout as well as from RFC 3339, always as UTC, and encode back in the layout
+// of the API. The zero value encodes as null.
+type Timestamp struct {
+	time.Time
+}
+
+// ParseTimestamp parses a timestamp in the layout of the API or in RFC 3339, and returns it in UTC.
+func ParseTimestamp(s string) (Timestamp, error) {
+	t, err := time.Parse(TimestampLayout, s)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return Timestamp{}, fmt.Errorf("pkg: invalid timestamp %q", s)
+		}
+	}
+	return Timestamp{Time: t.UTC()}, nil
+}
+
+// String returns the timestamp in the layout of the API.
+func (t Timestamp) String() string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*t = Timestamp{}
+		return nil
+	}
+
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return fmt.Errorf("pkg: invalid timestamp %s", data)
+	}
+	if s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		out  string
+		err  bool
+	}{
+		{in: `"2018-02-27T08:25:31"`, want: time.Date(2018, 2, 27, 8, 25, 31, 0, time.UTC), out: `"2018-02-27T08:25:31"`},
+		{in: `"2018-02-27T08:25:31.5"`, want: time.Date(2018, 2, 27, 8, 25, 31, 5e8, time.UTC), out: `"2018-02-27T08:25:31"`},
+		{in: `"2018-02-27T08:25:31Z"`, want: time.Date(2018, 2, 27, 8, 25, 31, 0, time.UTC), out: `"2018-02-27T08:25:31"`},
+		{in: `"2018-02-27T10:25:31+02:00"`, want: time.Date(2018, 2, 27, 8, 25, 31, 0, time.UTC), out: `"2018-02-27T08:25:31"`},
+		{in: `null`, out: `null`},
+		{in: `""`, out: `null`},
+		{in: `"27/02/2018"`, err: true},
+		{in: `1519719931`, err: true},
+	}
+
+	for _, tt := range tests {
+		var ts Timestamp
+		err := json.Unmarshal([]byte(tt.in), &ts)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %s, want an error", tt.in, ts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if !ts.Equal(tt.want) || ts.Location() != time.UTC {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, ts.Time, tt.want)
+		}
+
+		out, err := json.Marshal(ts)
+		if err != nil {
+			t.Errorf("Marshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("Marshal(%s) = %s, want %s", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestQuotationExpiration(t *testing.T) {
+	var q Quotation
+	data := `{"creation_date":"2018-02-27T08:25:31","expiration_date":"2018-02-27T09:25:31"}`
+	if err := json.Unmarshal([]byte(data), &q); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !q.IsExpired() || q.TimeLeft() != 0 {
+		t.Errorf("IsExpired() = %t, TimeLeft() = %s, want an expired quotation", q.IsExpired(), q.TimeLeft())
+	}
+
+	q.ExpirationDate = Timestamp{Time: time.Now().Add(time.Hour)}
+	if q.IsExpired() || q.TimeLeft() <= 0 {
+		t.Errorf("IsExpired() = %t, TimeLeft() = %s, want a valid quotation", q.IsExpired(), q.TimeLeft())
+	}
+}
+
+func TestTransactionDates(t *testing.T) {
+	var tx Transaction
+	data := `{"id": 42, "creation_date": "2018-02-27T08:25:31", "expiration_date": null}`
+	if err := json.Unmarshal([]byte(data), &tx); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := time.Date(2018, 2, 27, 8, 25, 31, 0, time.UTC)
+	if tx.CreationDate == nil || !tx.CreationDate.Equal(want) {
+		t.Errorf("CreationDate = %v, want %v", tx.CreationDate, want)
+	}
+	if tx.ExpirationDate != nil {
+		t.Errorf("ExpirationDate = %v, want nil", tx.ExpirationDate)
+	}
+
+	out, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"creation_date":"2018-02-27T08:25:31","expiration_date":null`) {
+		t.Errorf("Marshal() = %s, want the dates in the layout of the API", out)
+	}
+}
@@ -1,34 +1,36 @@
 package pkg
 
+import "encoding/json"
+
 // Transaction represents transaction information for a transfer request.
 type Transaction struct {
 	ID                        *int                          `json:"id"`
-	Status                    *string                       `json:"status"`
+	Status                    *TransactionStatus            `json:"status"`
 	StatusMessage             *string                       `json:"status_message"`
-	StatusClass               *string                       `json:"status_class"`
+	StatusClass               *StatusClass                  `json:"status_class"`
 	StatusClassMessage        *string                       `json:"status_class_message"`
 	ExternalID                *string                       `json:"external_id"`
 	ExternalCode              *string                       `json:"external_code"`
-	TransactionType           *string                       `json:"transaction_type"`
+	TransactionType           *TransactionType              `json:"transaction_type"`
 	PayerTransactionReference *string                       `json:"payer_transaction_reference"`
 	PayerTransactionCode      *string                       `json:"payer_transaction_code"`
-	CreationDate              *string                       `json:"creation_date"`
-	ExpirationDate            *string                       `json:"expiration_date"`
+	CreationDate              *Timestamp                    `json:"creation_date"`
+	ExpirationDate            *Timestamp                    `json:"expiration_date"`
 	CreditPartyIdentifier     *CreditPartyIdentifier        `json:"credit_party_identifier"`
 	Source                    *Source                       `json:"source"`
-	Destination               *CurrencyAmount               `json:"destination"`
+	Destination               *Money                        `json:"destination"`
 	Payer                     *Payer                        `json:"payer"`
 	Sender                    *Sender                       `json:"sender"`
 	Beneficiary               *Beneficiary                  `json:"beneficiary"`
 	SendingBusiness           *SendingBusinessInformation   `json:"sending_business"`
 	ReceivingBusiness         *ReceivingBusinessInformation `json:"receiving_business"`
 	CallbackURL               *string                       `json:"callback_url"`
-	SendAmount                *CurrencyAmount               `json:"send_amount"`
-	WholeSaleFXRate           *float64                      `json:"wholesale_fx_rate"`
-	RetailRate                *float64                      `json:"retail_rate"`
-	RetailFee                 *float64                      `json:"retail_fee"`
+	SendAmount                *Money                        `json:"send_amount"`
+	WholeSaleFXRate           *Decimal                      `json:"wholesale_fx_rate"`
+	RetailRate                *Decimal                      `json:"retail_rate"`
+	RetailFee                 *Decimal                      `json:"retail_fee"`
 	RetailFeeCurrency         *string                       `json:"retail_fee_currency"`
-	Fee                       *CurrencyAmount               `json:"fee"`
+	Fee                       *Money                        `json:"fee"`
 	PurposeOfRemittance       *string                       `json:"purpose_of_remittance"`
 	DocumentReferenceNumber   *string                       `json:"document_reference_number"`
 	AdditionalInformation1    *string                       `json:"additional_information_1"`
@@ -45,8 +47,8 @@ type Transaction struct {
 //		B2B will require a sending business and a receiving business
 type CreateTransactionRequest struct {
 	CreditPartyIdentifier   *CreditPartyIdentifier        `json:"credit_party_identifier"`   // mandatory
-	RetailFee               *float64                      `json:"retail_fee"`                // optional
-	RetailRate              *float64                      `json:"retail_rate"`               // optional
+	RetailFee               *Decimal                      `json:"retail_fee"`                // optional
+	RetailRate              *Decimal                      `json:"retail_rate"`               // optional
 	RetailFeeCurrency       *string                       `json:"retail_fee_currency"`       // optional
 	Sender                  *Sender                       `json:"sender"`                    // depends on transaction type
 	Beneficiary             *Beneficiary                  `json:"beneficiary"`               // depends on transaction type
@@ -62,13 +64,23 @@ type CreateTransactionRequest struct {
 	AdditionalInformation3  *string                       `json:"additional_information_3"`  // optional
 }
 
+// MarshalJSON encodes the retail fee and rate of the request as JSON numbers, as the API documents them,
+// even when they were decoded from JSON strings, e.g. from a payout file.
+func (r CreateTransactionRequest) MarshalJSON() ([]byte, error) {
+	type plain CreateTransactionRequest
+	p := plain(r)
+	p.RetailFee = unquoted(r.RetailFee)
+	p.RetailRate = unquoted(r.RetailRate)
+	return json.Marshal(p)
+}
+
 // TransactionAttachment represents an attachment which has been added to a transaction.
 type TransactionAttachment struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	Name          string `json:"name"`
-	ContentType   string `json:"content_type"`
-	Type          string `json:"type"`
+	ID            int                       `json:"id"`
+	TransactionID int                       `json:"transaction_id"`
+	Name          string                    `json:"name"`
+	ContentType   string                    `json:"content_type"`
+	Type          TransactionAttachmentType `json:"type"`
 }
 
 type TransactionAttachmentType string
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldError reports a field of a transaction request which does not satisfy the requirements of the payer.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "sender.lastname".
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every problem found by Validate.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "invalid transaction request: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks a transaction request against the requirements the payer publishes for the transaction type,
+// so that it can be fixed before it is sent. It returns a *ValidationError listing every problem found:
+//   - the required fields of the sending entity (Sender for C2C and C2B, SendingBusiness for B2C and B2B),
+//     and of the receiving entity (Beneficiary for C2C and B2C, ReceivingBusiness for C2B and B2B);
+//   - the credit party identifier, which must hold one of the accepted combinations of fields,
+//     each of them in a valid format (see CreditPartyIdentifier.Validate);
+//   - the external id, and the purpose of remittance and document reference number of B2B transactions;
+//   - the gender of the sender and of the beneficiary, if set.
+//
+// Each group of a required fields list must have at least one field set, while every field of a group of
+// accepted credit party identifiers must be set. The required documents are not checked, they are attached
+// once the transaction is created. A payer which does not publish its transaction types is only checked
+// for the fields Thunes always requires.
+func Validate(payer *Payer, transactionType TransactionType, req *CreateTransactionRequest) error {
+	verr := &ValidationError{}
+
+	info, ok := payer.TransactionTypes[transactionType]
+	if !ok && len(payer.TransactionTypes) > 0 {
+		verr.add("transaction_type", "%s is not supported by payer %d", transactionType, payer.ID)
+		return verr
+	}
+
+	if req.ExternalID == nil || *req.ExternalID == "" {
+		verr.add("external_id", "is mandatory")
+	}
+	if transactionType == B2B {
+		if req.PurposeOfRemittance == nil || *req.PurposeOfRemittance == "" {
+			verr.add("purpose_of_remittance", "is mandatory for B2B transactions")
+		}
+		if req.DocumentReferenceNumber == nil || *req.DocumentReferenceNumber == "" {
+			verr.add("document_reference_number", "is mandatory for B2B transactions")
+		}
+	}
+
+	validateCreditParty(verr, info.CreditPartyIndentifiersAccepted, req.CreditPartyIdentifier)
+
+	// the entities of each side depend on whether it is a consumer or a business
+	var sending, receiving interface{}
+	var sendingName, receivingName string
+	if transactionType.IsBusinessSender() {
+		sending, sendingName = req.SendingBusiness, "sending_business"
+	} else {
+		sending, sendingName = req.Sender, "sender"
+	}
+	if transactionType.IsBusinessReceiver() {
+		receiving, receivingName = req.ReceivingBusiness, "receiving_business"
+	} else {
+		receiving, receivingName = req.Beneficiary, "beneficiary"
+	}
+	validateEntity(verr, sendingName, sending, info.RequiredSendingIdentityFields, transactionType)
+	validateEntity(verr, receivingName, receiving, info.RequiredReceivingIdentityFields, transactionType)
+	if req.Sender != nil && req.Sender.Gender != nil {
+		validateGender(verr, "sender.gender", *req.Sender.Gender)
+	}
+	if req.Beneficiary != nil && req.Beneficiary.Gender != nil {
+		validateGender(verr, "beneficiary.gender", *req.Beneficiary.Gender)
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateCreditParty(verr *ValidationError, accepted [][]string, identifier *CreditPartyIdentifier) {
+	if identifier == nil {
+		verr.add("credit_party_identifier", "is mandatory")
+		return
+	}
+	validateIdentifierFormats(verr, identifier)
+	if len(accepted) == 0 {
+		return
+	}
+
+	combinations := make([]string, len(accepted))
+	for i, fields := range accepted {
+		complete := true
+		for _, field := range fields {
+			if set, _ := fieldSet(identifier, field); !set {
+				complete = false
+			}
+		}
+		if complete {
+			return
+		}
+		combinations[i] = strings.Join(fields, " and ")
+	}
+
+	verr.add("credit_party_identifier", "must contain one of: %s", strings.Join(combinations, "; "))
+}
+
+func validateGender(verr *ValidationError, field string, gender Gender) {
+	if gender.Validate() != nil {
+		verr.add(field, "must be %s or %s", Male, Female)
+	}
+}
+
+func validateEntity(verr *ValidationError, name string, entity interface{}, required [][]string, transactionType TransactionType) {
+	if reflect.ValueOf(entity).IsNil() && len(required) == 0 {
+		verr.add(name, "is mandatory for %s transactions", transactionType)
+		return
+	}
+
+	for _, group := range required {
+		var set, known bool
+		for _, field := range group {
+			s, k := fieldSet(entity, field)
+			set, known = set || s, known || k
+		}
+		if set {
+			continue
+		}
+
+		path := name + "." + strings.Join(group, " or "+name+".")
+		switch {
+		case !known:
+			verr.add(path, "is required by the payer but cannot be set on %T", entity)
+		case len(group) > 1:
+			verr.add(path, "one of them is required")
+		default:
+			verr.add(path, "is required")
+		}
+	}
+}
+
+// fieldSet reports whether the field of the struct pointed to by v with the given JSON name is set,
+// and whether the struct has such a field at all. A nil pointer has no field set.
+func fieldSet(v interface{}, name string) (set, known bool) {
+	rv := reflect.ValueOf(v)
+	t := rv.Type().Elem()
+
+	for i := 0; i < t.NumField(); i++ {
+		if tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; tag != name {
+			continue
+		}
+		if rv.IsNil() {
+			return false, true
+		}
+
+		field := rv.Elem().Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return false, true
+			}
+			field = field.Elem()
+		}
+		return !field.IsZero(), true
+	}
+
+	return false, false
+}
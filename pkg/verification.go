@@ -10,8 +10,8 @@ UNAVAILABLE-BARRED-ACCOUNT 			Credit party account number is barred
 */
 
 type VerificationStatus struct {
-	ID            int    `json:"id"`
-	AccountStatus string `json:"account_status"`
+	ID            int           `json:"id"`
+	AccountStatus AccountStatus `json:"account_status"`
 }
 
 type VerificationStatusRequest struct {
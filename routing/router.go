@@ -0,0 +1,342 @@
+// Package routing picks the best payer for a payout among the payers of the catalog
+// serving its country, currency and service.
+//
+// A Router ranks the payers by the effective FX rate of an offline estimate, fee included,
+// after dropping the blocked payers and the ones whose limits, rates or required fields
+// rule the payout out. Every route and rejection carries the reasons of its rank:
+//
+//	router := routing.NewRouter(cat, fx.NewEstimator(client), routing.WithPreferred(12), routing.WithBlocked(7))
+//	result, err := router.Rank(ctx, routing.Request{
+//		CountryISOCode:  "KEN",
+//		Currency:        "KES",
+//		TransactionType: pkg.C2C,
+//		Mode:            pkg.SourceAmount,
+//		Amount:          pkg.NewMoney(pkg.MustParseDecimal("100"), "USD"),
+//	})
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"thunes-client/catalog"
+	"thunes-client/fx"
+	"thunes-client/pkg"
+)
+
+// ErrNoRoute is returned when no payer can serve the payout.
+var ErrNoRoute = errors.New("routing: no payer available")
+
+// ratePlaces is the number of digits after the point of the effective rates.
+const ratePlaces = 8
+
+// Request describes the payout to route.
+type Request struct {
+	// CountryISOCode is the country of the payers, it is required.
+	CountryISOCode string
+	// Currency is the currency paid out, only the payers paying out in it are ranked. It is required in SourceAmount
+	// mode, and defaults to Amount's currency in DestinationAmount mode.
+	Currency string
+	// ServiceID is the service of the payers, it is optional.
+	ServiceID int
+
+	TransactionType pkg.TransactionType
+	Mode            pkg.QuotationMode
+	// Amount is the amount sent in SourceAmount mode, the amount paid out in DestinationAmount mode.
+	Amount pkg.Money
+	// SourceCurrency is the currency sent in DestinationAmount mode, Amount's currency is used in SourceAmount mode.
+	SourceCurrency string
+	// SourceCountryISOCode is the country of the sender, it is required by Quote.
+	SourceCountryISOCode string
+
+	// Transaction is the transaction to send. When set, the payers whose required fields it does not satisfy are rejected.
+	Transaction *pkg.CreateTransactionRequest
+}
+
+func (r *Request) sourceCurrency() string {
+	if r.Mode == pkg.DestinationAmount {
+		return r.SourceCurrency
+	}
+	return r.Amount.Currency
+}
+
+// Route is a payer able to serve the payout.
+type Route struct {
+	Payer    pkg.Payer
+	Estimate *fx.Estimate
+	// Fee is the fee of the payer configured with WithFees, zero if unknown.
+	Fee pkg.Money
+	// EffectiveRate is the amount paid out per unit sent, fee included. The higher the better.
+	EffectiveRate pkg.Decimal
+	Preferred     bool
+	Reasons       []string
+}
+
+// Rejection is a payer unable to serve the payout.
+type Rejection struct {
+	Payer   pkg.Payer
+	Reasons []string
+}
+
+// Result lists the routes of a payout, best first, and the payers rejected.
+type Result struct {
+	Routes   []Route
+	Rejected []Rejection
+}
+
+// Router ranks the payers of a catalog for payouts, safe for concurrent use.
+type Router struct {
+	catalog   *catalog.Catalog
+	estimator *fx.Estimator
+	preferred map[int]int // rank of the preferred payers
+	blocked   map[int]bool
+	fees      map[int]pkg.Money
+}
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithPreferred ranks the given payers first, in the given order, whatever their effective rate.
+func WithPreferred(payerIDs ...int) Option {
+	return func(r *Router) {
+		for _, id := range payerIDs {
+			if _, ok := r.preferred[id]; !ok {
+				r.preferred[id] = len(r.preferred)
+			}
+		}
+	}
+}
+
+// WithBlocked never routes payouts to the given payers.
+func WithBlocked(payerIDs ...int) Option {
+	return func(r *Router) {
+		for _, id := range payerIDs {
+			r.blocked[id] = true
+		}
+	}
+}
+
+// WithFees sets the fee charged by the payers, keyed by payer id, so that it is included in their effective rate.
+// The fees are in the source currency, the ones in another currency are ignored.
+func WithFees(fees map[int]pkg.Money) Option {
+	return func(r *Router) {
+		for id, fee := range fees {
+			r.fees[id] = fee
+		}
+	}
+}
+
+// NewRouter constructs a router ranking the payers of cat with the estimates of estimator.
+func NewRouter(cat *catalog.Catalog, estimator *fx.Estimator, opts ...Option) *Router {
+	r := &Router{
+		catalog:   cat,
+		estimator: estimator,
+		preferred: make(map[int]int),
+		blocked:   make(map[int]bool),
+		fees:      make(map[int]pkg.Money),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Rank returns the payers able to serve the payout, best first, and the reasons the others cannot.
+// The preferred payers come first, then the others by decreasing effective rate.
+// A payer whose rates cannot be fetched is rejected with the error as its reason.
+func (r *Router) Rank(ctx context.Context, req Request) (*Result, error) {
+	if req.CountryISOCode == "" {
+		return nil, errors.New("routing: the country of the payout must be supplied")
+	}
+	if req.Currency == "" && req.Mode == pkg.DestinationAmount {
+		req.Currency = req.Amount.Currency
+	}
+	// the effective rates of payers paying out in different currencies cannot be compared
+	if req.Currency == "" {
+		return nil, errors.New("routing: the currency paid out must be supplied")
+	}
+	if err := req.TransactionType.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.Mode.Validate(); err != nil {
+		return nil, err
+	}
+
+	payers, err := r.catalog.FindPayers(ctx, catalog.Filter{
+		CountryISOCode:  req.CountryISOCode,
+		Currency:        req.Currency,
+		ServiceID:       req.ServiceID,
+		TransactionType: req.TransactionType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for i := range payers {
+		route, reasons, err := r.route(ctx, &req, &payers[i])
+		if err != nil {
+			return nil, err
+		}
+		if route == nil {
+			result.Rejected = append(result.Rejected, Rejection{Payer: payers[i], Reasons: reasons})
+			continue
+		}
+		result.Routes = append(result.Routes, *route)
+	}
+
+	sort.SliceStable(result.Routes, func(i, j int) bool {
+		a, b := &result.Routes[i], &result.Routes[j]
+		if a.Preferred != b.Preferred {
+			return a.Preferred
+		}
+		if a.Preferred {
+			return r.preferred[a.Payer.ID] < r.preferred[b.Payer.ID]
+		}
+		if c := a.EffectiveRate.Cmp(b.EffectiveRate); c != 0 {
+			return c > 0
+		}
+		return a.Payer.ID < b.Payer.ID
+	})
+
+	return result, nil
+}
+
+// route estimates the payout with the payer. It returns a nil route and the reasons if the payer cannot serve it,
+// e.g. its rates could not be fetched, and an error only when the context is done.
+func (r *Router) route(ctx context.Context, req *Request, payer *pkg.Payer) (*Route, []string, error) {
+	if r.blocked[payer.ID] {
+		return nil, []string{"blocked"}, nil
+	}
+
+	if req.Transaction != nil {
+		if err := pkg.Validate(payer, req.TransactionType, req.Transaction); err != nil {
+			var verr *pkg.ValidationError
+			if !errors.As(err, &verr) {
+				return nil, nil, err
+			}
+			reasons := make([]string, len(verr.Errors))
+			for i, fieldErr := range verr.Errors {
+				reasons[i] = fieldErr.Error()
+			}
+			return nil, reasons, nil
+		}
+	}
+
+	var estimate *fx.Estimate
+	var err error
+	if req.Mode == pkg.SourceAmount {
+		estimate, err = r.estimator.EstimateSource(ctx, payer, req.TransactionType, req.Amount)
+	} else {
+		estimate, err = r.estimator.EstimateDestination(ctx, payer, req.TransactionType, req.SourceCurrency, req.Amount)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return nil, []string{err.Error()}, nil
+	}
+
+	route := &Route{
+		Payer:     *payer,
+		Estimate:  estimate,
+		Fee:       pkg.NewMoney(pkg.NewDecimal(0, pkg.MinorUnits(estimate.Source.Currency)), estimate.Source.Currency),
+		Preferred: r.isPreferred(payer.ID),
+	}
+	if route.Preferred {
+		route.Reasons = append(route.Reasons, "preferred")
+	}
+
+	if fee, ok := r.fees[payer.ID]; ok {
+		if _, err := fee.Cmp(estimate.Source); err == nil {
+			route.Fee = fee
+		} else {
+			route.Reasons = append(route.Reasons, fmt.Sprintf("fee ignored: %v", err))
+		}
+	}
+
+	cost, _ := estimate.Source.Add(route.Fee)
+	if cost.IsZero() {
+		return nil, []string{"nothing to send"}, nil
+	}
+	route.EffectiveRate = estimate.Destination.Amount.Div(cost.Amount, ratePlaces)
+	route.Reasons = append(route.Reasons,
+		fmt.Sprintf("wholesale rate %s, tier %s to %s", estimate.WholesaleFXRate, estimate.Tier.SourceAmountMin, estimate.Tier.SourceAmountMax),
+		fmt.Sprintf("%s for %s, fee %s", estimate.Destination, cost, route.Fee),
+		fmt.Sprintf("effective rate %s", route.EffectiveRate),
+	)
+
+	return route, nil, nil
+}
+
+func (r *Router) isPreferred(payerID int) bool {
+	_, ok := r.preferred[payerID]
+	return ok
+}
+
+// Quoter creates quotations, *api.ThunesClient implements it.
+type Quoter interface {
+	CreateQuotationForSource(ctx context.Context, sourceAmt pkg.Decimal, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode string, transactionType pkg.TransactionType, externalID string) (*pkg.Quotation, error)
+	CreateQuotationForDestination(ctx context.Context, destinationAmt pkg.Decimal, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode string, transactionType pkg.TransactionType, externalID string) (*pkg.Quotation, error)
+}
+
+// QuoteError lists the failed quotations of Quote, by payer id.
+type QuoteError struct {
+	Errors map[int]error
+}
+
+func (e *QuoteError) Error() string {
+	ids := make([]int, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	msg := "routing: no quotation could be created"
+	for _, id := range ids {
+		msg += "; payer " + strconv.Itoa(id) + ": " + e.Errors[id].Error()
+	}
+	return msg
+}
+
+// Quote ranks the payers and creates the quotation of the payout with the best one, falling back to the next one
+// whenever the quotation fails. Each quotation is created with the external id externalID-p<payer id>: a quotation
+// created by a request whose response was lost must neither block the next payers nor be taken for theirs.
+// It returns ErrNoRoute if no payer can serve the payout, or a *QuoteError if every quotation failed.
+func (r *Router) Quote(ctx context.Context, quoter Quoter, req Request, externalID string) (*pkg.Quotation, *Route, error) {
+	result, err := r.Rank(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(result.Routes) == 0 {
+		return nil, nil, ErrNoRoute
+	}
+
+	qerr := &QuoteError{Errors: make(map[int]error)}
+	for i := range result.Routes {
+		route := &result.Routes[i]
+		payerID := strconv.Itoa(route.Payer.ID)
+		quotationID := externalID + "-p" + payerID
+
+		var quotation *pkg.Quotation
+		if req.Mode == pkg.SourceAmount {
+			quotation, err = quoter.CreateQuotationForSource(ctx, req.Amount.Amount, route.Payer.Currency, req.sourceCurrency(), payerID, req.SourceCountryISOCode, req.TransactionType, quotationID)
+		} else {
+			quotation, err = quoter.CreateQuotationForDestination(ctx, req.Amount.Amount, route.Payer.Currency, req.sourceCurrency(), payerID, req.SourceCountryISOCode, req.TransactionType, quotationID)
+		}
+		if err == nil {
+			return quotation, route, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		qerr.Errors[route.Payer.ID] = err
+	}
+
+	return nil, nil, qerr
+}
@@ -0,0 +1,157 @@
+package routing_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"thunes-client/api"
+	"thunes-client/catalog"
+	"thunes-client/fx"
+	"thunes-client/pkg"
+	"thunes-client/routing"
+	"thunes-client/thunestest"
+)
+
+func newRouter(t *testing.T) (*thunestest.Server, *routing.Router) {
+	t.Helper()
+	srv := thunestest.NewServer()
+	t.Cleanup(srv.Close)
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry))
+	return srv, routing.NewRouter(catalog.New(tc), fx.NewEstimator(tc))
+}
+
+func TestRank(t *testing.T) {
+	_, router := newRouter(t)
+
+	result, err := router.Rank(context.Background(), routing.Request{
+		CountryISOCode:  "KEN",
+		Currency:        "KES",
+		TransactionType: pkg.C2C,
+		Mode:            pkg.SourceAmount,
+		Amount:          pkg.NewMoney(pkg.DecimalFromInt(100), "USD"),
+	})
+	if err != nil {
+		t.Fatalf("Rank() error = %v", err)
+	}
+	if len(result.Routes) != 1 || result.Routes[0].Payer.ID != 1 {
+		t.Fatalf("Routes = %+v, want payer 1", result.Routes)
+	}
+	if got := result.Routes[0].Estimate.Destination.String(); got != "12850.00 KES" {
+		t.Errorf("destination = %s, want 12850.00 KES", got)
+	}
+}
+
+func TestRankRequiresCurrency(t *testing.T) {
+	_, router := newRouter(t)
+
+	_, err := router.Rank(context.Background(), routing.Request{
+		CountryISOCode:  "KEN",
+		TransactionType: pkg.C2C,
+		Mode:            pkg.SourceAmount,
+		Amount:          pkg.NewMoney(pkg.DecimalFromInt(100), "USD"),
+	})
+	if err == nil {
+		t.Error("Rank() without a currency succeeded")
+	}
+
+	// the amount is in the currency paid out in DestinationAmount mode
+	result, err := router.Rank(context.Background(), routing.Request{
+		CountryISOCode:  "KEN",
+		TransactionType: pkg.C2C,
+		Mode:            pkg.DestinationAmount,
+		Amount:          pkg.NewMoney(pkg.DecimalFromInt(1000), "KES"),
+		SourceCurrency:  "USD",
+	})
+	if err != nil {
+		t.Fatalf("Rank() error = %v", err)
+	}
+	if len(result.Routes) != 1 {
+		t.Errorf("Routes = %+v, want payer 1", result.Routes)
+	}
+}
+
+func TestRankRejectsPayerWithoutRates(t *testing.T) {
+	srv, router := newRouter(t)
+	srv.InjectFault(thunestest.Fault{
+		Method:     http.MethodGet,
+		Path:       "/v2/money-transfer/payers/1/rates",
+		StatusCode: http.StatusInternalServerError,
+	})
+
+	result, err := router.Rank(context.Background(), routing.Request{
+		CountryISOCode:  "KEN",
+		Currency:        "KES",
+		TransactionType: pkg.C2C,
+		Mode:            pkg.SourceAmount,
+		Amount:          pkg.NewMoney(pkg.DecimalFromInt(100), "USD"),
+	})
+	if err != nil {
+		t.Fatalf("Rank() error = %v", err)
+	}
+	if len(result.Routes) != 0 || len(result.Rejected) != 1 {
+		t.Fatalf("result = %+v, want payer 1 rejected", result)
+	}
+	if reasons := strings.Join(result.Rejected[0].Reasons, "; "); !strings.Contains(reasons, "500") {
+		t.Errorf("reasons = %q, want the error of the rates request", reasons)
+	}
+}
+
+// lostQuoter creates the quotations of the first payer but loses the response, as a timed out request would.
+type lostQuoter struct {
+	routing.Quoter
+	lost        string
+	externalIDs []string
+}
+
+func (q *lostQuoter) CreateQuotationForSource(ctx context.Context, sourceAmt pkg.Decimal, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode string, transactionType pkg.TransactionType, externalID string) (*pkg.Quotation, error) {
+	q.externalIDs = append(q.externalIDs, externalID)
+	quotation, err := q.Quoter.CreateQuotationForSource(ctx, sourceAmt, destinationCurrency, sourceCurrency, payerID, sourceCountryISOCode, transactionType, externalID)
+	if err == nil && payerID == q.lost {
+		return nil, context.DeadlineExceeded
+	}
+	return quotation, err
+}
+
+func TestQuoteFallsBackAfterLostResponse(t *testing.T) {
+	// a second payer in Kenya, with a worse rate than M-Pesa
+	fixtures := thunestest.DefaultFixtures()
+	airtel := fixtures.Payers[0]
+	airtel.ID, airtel.Name = 3, "Airtel Kenya"
+	fixtures.Payers = append(fixtures.Payers, airtel)
+	fixtures.Rates[3] = pkg.PayerRates{
+		DestinationCurrency: "KES",
+		Rates: map[pkg.TransactionType]map[string][]pkg.Rates{
+			pkg.C2C: {"USD": {{SourceAmountMin: pkg.DecimalFromInt(0), SourceAmountMax: pkg.DecimalFromInt(100000), WholesaleFXRate: pkg.MustParseDecimal("120")}}},
+		},
+	}
+	fixtures.Fees[3] = pkg.MustParseDecimal("2.00")
+
+	srv := thunestest.NewServer(thunestest.WithFixtures(fixtures))
+	defer srv.Close()
+	tc := srv.Client(api.WithRetryPolicy(api.NoRetry))
+	router := routing.NewRouter(catalog.New(tc), fx.NewEstimator(tc))
+
+	quoter := &lostQuoter{Quoter: tc, lost: "1"}
+	req := routing.Request{
+		CountryISOCode:  "KEN",
+		Currency:        "KES",
+		TransactionType: pkg.C2C,
+		Mode:            pkg.SourceAmount,
+		Amount:          pkg.NewMoney(pkg.DecimalFromInt(100), "USD"),
+	}
+	quotation, route, err := router.Quote(context.Background(), quoter, req, "payout-1")
+	if err != nil {
+		t.Fatalf("Quote() error = %v", err)
+	}
+	if route.Payer.ID != 3 || quotation.Payer.ID != 3 {
+		t.Errorf("quoted payer %d with route %d, want the fallback payer 3", quotation.Payer.ID, route.Payer.ID)
+	}
+	if quotation.ExternalID != "payout-1-p3" {
+		t.Errorf("ExternalID = %s, want payout-1-p3", quotation.ExternalID)
+	}
+	if got := strings.Join(quoter.externalIDs, ","); got != "payout-1-p1,payout-1-p3" {
+		t.Errorf("external ids = %s, want one per payer", got)
+	}
+}
@@ -0,0 +1,87 @@
+package thunestest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault describes a failure injected into the requests matching Method and Path.
+type Fault struct {
+	// Method matches the request method, empty matches every method.
+	Method string
+	// Path matches the requests whose path starts with it, e.g. "/v2/money-transfer/quotations". Empty matches every path.
+	Path string
+
+	// StatusCode is the status answered instead of the real response. Zero only delays the request.
+	StatusCode int
+	// Body is the raw response body, it defaults to a Thunes error document.
+	Body string
+	// Header is added to the response, e.g. a Retry-After header.
+	Header http.Header
+	// Delay is waited before answering.
+	Delay time.Duration
+
+	// AfterHandling processes the request for real before answering with the fault,
+	// simulating a response lost on its way back to the client.
+	AfterHandling bool
+
+	// Times is the number of requests affected, zero affects every matching request.
+	Times int
+}
+
+// InjectFault makes the requests matching the fault fail.
+// Faults are matched in the order they were injected.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// matchFault returns the first fault matching the request and consumes one of its occurrences.
+// It must be called with s.mu held.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+func (f *Fault) write(w http.ResponseWriter) {
+	for key, values := range f.Header {
+		w.Header()[key] = values
+	}
+
+	if f.Body == "" {
+		writeError(w, f.StatusCode, "1000"+strconv.Itoa(f.StatusCode), http.StatusText(f.StatusCode))
+		return
+	}
+
+	w.WriteHeader(f.StatusCode)
+	_, _ = w.Write([]byte(f.Body))
+}
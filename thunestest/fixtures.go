@@ -0,0 +1,150 @@
+package thunestest
+
+import "thunes-client/pkg"
+
+// Fixtures is the catalog and account data served by a Server.
+type Fixtures struct {
+	Services  []pkg.Service
+	Countries []pkg.Country
+	Payers    []pkg.Payer
+	Balances  []pkg.Balance
+
+	// Rates holds the rates of every payer, keyed by payer id.
+	Rates map[int]pkg.PayerRates
+	// Fees holds the fee charged by every payer in the source currency, keyed by payer id.
+	Fees map[int]pkg.Decimal
+	// Lookups holds the payers' identifiers returned for a SWIFT BIC code.
+	Lookups map[string][]pkg.Lookup
+
+	// AccountStatuses holds the credit party verification result of an account,
+	// keyed by its MSISDN, IBAN or account number. Unknown accounts are AVAILABLE.
+	AccountStatuses map[string]pkg.AccountStatus
+	// Beneficiaries holds the credit party information of an account, keyed like AccountStatuses.
+	Beneficiaries map[string]pkg.Beneficiary
+	// ReceivingBusinesses holds the credit party information of a business account, keyed like AccountStatuses.
+	ReceivingBusinesses map[string]pkg.ReceivingBusinessInformation
+}
+
+// DefaultFixtures returns a small catalog with a mobile wallet payer in Kenya and a bank payer in the Philippines,
+// funded USD and EUR balances and a few well-known accounts.
+func DefaultFixtures() Fixtures {
+	mobileWallet := pkg.Service{ID: 1, Name: "MobileWallet"}
+	bankAccount := pkg.Service{ID: 2, Name: "BankAccount"}
+	cashPickup := pkg.Service{ID: 3, Name: "CashPickup"}
+
+	return Fixtures{
+		Services: []pkg.Service{mobileWallet, bankAccount, cashPickup},
+		Countries: []pkg.Country{
+			{ISOCode: "KEN", Name: "Kenya"},
+			{ISOCode: "PHL", Name: "Philippines"},
+		},
+		Payers: []pkg.Payer{
+			{
+				ID:                   1,
+				Name:                 "M-Pesa Kenya",
+				Precision:            2,
+				Increment:            dec("0.01"),
+				Currency:             "KES",
+				CountryISOCode:       "KEN",
+				MinTransactionAmount: dec("1"),
+				MaxTransactionAmount: dec("150000"),
+				Service:              mobileWallet,
+				TransactionTypes: map[pkg.TransactionType]pkg.BaseTransactionInfomation{
+					pkg.C2C: {
+						MinTransactionAmount:            dec("1.00").Quoted(),
+						MaxTransactionAmount:            dec("150000.00").Quoted(),
+						CreditPartyIndentifiersAccepted: [][]string{{"msisdn"}},
+						RequiredSendingIdentityFields:   [][]string{{"lastname"}, {"firstname"}, {"country_iso_code"}},
+						RequiredReceivingIdentityFields: [][]string{{"lastname"}, {"firstname"}},
+						RequiredDocuments:               [][]string{},
+					},
+				},
+			},
+			{
+				ID:                   2,
+				Name:                 "BDO Philippines",
+				Precision:            2,
+				Increment:            dec("0.01"),
+				Currency:             "PHP",
+				CountryISOCode:       "PHL",
+				MinTransactionAmount: dec("100"),
+				MaxTransactionAmount: dec("500000"),
+				Service:              bankAccount,
+				TransactionTypes: map[pkg.TransactionType]pkg.BaseTransactionInfomation{
+					pkg.C2C: {
+						MinTransactionAmount:            dec("100.00").Quoted(),
+						MaxTransactionAmount:            dec("500000.00").Quoted(),
+						CreditPartyIndentifiersAccepted: [][]string{{"bank_account_number", "swift_bic_code"}},
+						RequiredSendingIdentityFields:   [][]string{{"lastname"}, {"firstname"}, {"id_number"}},
+						RequiredReceivingIdentityFields: [][]string{{"lastname"}, {"firstname"}},
+						RequiredDocuments:               [][]string{},
+					},
+					pkg.B2B: {
+						MinTransactionAmount:            dec("100.00").Quoted(),
+						MaxTransactionAmount:            dec("500000.00").Quoted(),
+						CreditPartyIndentifiersAccepted: [][]string{{"bank_account_number", "swift_bic_code"}},
+						RequiredSendingIdentityFields:   [][]string{{"registered_name"}, {"country_iso_code"}},
+						RequiredReceivingIdentityFields: [][]string{{"registered_name"}, {"country_iso_code"}},
+						RequiredDocuments:               [][]string{{"invoice"}},
+					},
+				},
+			},
+		},
+		Balances: []pkg.Balance{
+			{ID: 1, Currency: "USD", Balance: dec("100000.00"), Available: dec("100000.00")},
+			{ID: 2, Currency: "EUR", Balance: dec("50000.00"), Available: dec("50000.00")},
+		},
+		Rates: map[int]pkg.PayerRates{
+			1: {
+				DestinationCurrency: "KES",
+				Rates: map[pkg.TransactionType]map[string][]pkg.Rates{
+					pkg.C2C: {
+						"USD": {
+							{SourceAmountMin: dec("0"), SourceAmountMax: dec("1000"), WholesaleFXRate: dec("128.5")},
+							{SourceAmountMin: dec("1000"), SourceAmountMax: dec("100000"), WholesaleFXRate: dec("129.1")},
+						},
+						"EUR": {
+							{SourceAmountMin: dec("0"), SourceAmountMax: dec("100000"), WholesaleFXRate: dec("139.2")},
+						},
+					},
+				},
+			},
+			2: {
+				DestinationCurrency: "PHP",
+				Rates: map[pkg.TransactionType]map[string][]pkg.Rates{
+					pkg.C2C: {
+						"USD": {{SourceAmountMin: dec("0"), SourceAmountMax: dec("100000"), WholesaleFXRate: dec("56.2")}},
+					},
+					pkg.B2B: {
+						"USD": {{SourceAmountMin: dec("0"), SourceAmountMax: dec("1000000"), WholesaleFXRate: dec("56.4")}},
+					},
+				},
+			},
+		},
+		Fees: map[int]pkg.Decimal{1: dec("2.00"), 2: dec("5.00")},
+		Lookups: map[string][]pkg.Lookup{
+			"BNORPHMM": {{ID: "2"}},
+		},
+		AccountStatuses: map[string]pkg.AccountStatus{
+			"254700000001": pkg.AccountAvailable,
+			"254700000002": pkg.AccountUnregistered,
+			"254700000003": pkg.AccountBarred,
+		},
+		Beneficiaries: map[string]pkg.Beneficiary{
+			"254700000001": {FirstName: strPtr("Jane"), LastName: strPtr("Wanjiru")},
+		},
+		ReceivingBusinesses: map[string]pkg.ReceivingBusinessInformation{},
+	}
+}
+
+func dec(s string) pkg.Decimal {
+	return pkg.MustParseDecimal(s)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,660 @@
+package thunestest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+// statusMessages holds the message of every status the server knows.
+var statusMessages = map[pkg.TransactionStatus]string{
+	pkg.StatusCreated:                   "CREATED",
+	pkg.StatusConfirmed:                 "CONFIRMED",
+	pkg.StatusConfirmedUnderReview:      "CONFIRMED-UNDER-REVIEW-SLS",
+	pkg.StatusConfirmedWaitingForPickup: "CONFIRMED-WAITING-FOR-PICKUP",
+	pkg.StatusRejected:                  "REJECTED",
+	pkg.StatusCancelled:                 "CANCELLED",
+	pkg.StatusSubmitted:                 "SUBMITTED",
+	pkg.StatusAvailable:                 "AVAILABLE",
+	pkg.StatusCompleted:                 "COMPLETED",
+	pkg.StatusReversed:                  "REVERSED",
+	pkg.StatusDeclined:                  "DECLINED",
+}
+
+// Error codes returned by the server besides the ones exported by the api package.
+const (
+	codeBadRequest      = "1000400"
+	codeNotFound        = "1000404"
+	codeNoRate          = "1005003"
+	codeInvalidStatus   = "1007010"
+	codeTooManyAttached = "1007020"
+)
+
+// maxAttachments is the number of files which can be attached to a transaction.
+const maxAttachments = 3
+
+func (s *Server) ping(r *http.Request) (int, interface{}, http.Header) {
+	return http.StatusOK, pkg.Status{Status: "up"}, nil
+}
+
+func (s *Server) listServices(r *http.Request) (int, interface{}, http.Header) {
+	services := []pkg.Service{}
+	country := r.URL.Query().Get("country_iso_code")
+	for _, service := range s.fixtures.Services {
+		if country != "" && !s.serviceAvailableIn(service.ID, country) {
+			continue
+		}
+		services = append(services, service)
+	}
+
+	from, to, header := paginate(r, len(services))
+	return http.StatusOK, services[from:to], header
+}
+
+// serviceAvailableIn reports whether a payer offers the service in the country.
+func (s *Server) serviceAvailableIn(serviceID int, country string) bool {
+	for _, payer := range s.fixtures.Payers {
+		if payer.Service.ID == serviceID && payer.CountryISOCode == country {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) listCountries(r *http.Request) (int, interface{}, http.Header) {
+	countries := append([]pkg.Country{}, s.fixtures.Countries...)
+	from, to, header := paginate(r, len(countries))
+	return http.StatusOK, countries[from:to], header
+}
+
+func (s *Server) listBalances(r *http.Request) (int, interface{}, http.Header) {
+	balances := append([]pkg.Balance{}, s.fixtures.Balances...)
+	from, to, header := paginate(r, len(balances))
+	return http.StatusOK, balances[from:to], header
+}
+
+func (s *Server) lookupBIC(bic string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		lookups := append([]pkg.Lookup{}, s.fixtures.Lookups[bic]...)
+		from, to, header := paginate(r, len(lookups))
+		return http.StatusOK, lookups[from:to], header
+	}
+}
+
+func (s *Server) listPayers(r *http.Request) (int, interface{}, http.Header) {
+	q := r.URL.Query()
+	payers := []pkg.Payer{}
+	for _, payer := range s.fixtures.Payers {
+		if id := q.Get("service_id"); id != "" && id != strconv.Itoa(payer.Service.ID) {
+			continue
+		}
+		if country := q.Get("country_iso_code"); country != "" && country != payer.CountryISOCode {
+			continue
+		}
+		if currency := q.Get("currency"); currency != "" && currency != payer.Currency {
+			continue
+		}
+		payers = append(payers, payer)
+	}
+
+	from, to, header := paginate(r, len(payers))
+	return http.StatusOK, payers[from:to], header
+}
+
+// payer returns the payer with the given id.
+func (s *Server) payer(id string) (*pkg.Payer, bool) {
+	for i, payer := range s.fixtures.Payers {
+		if strconv.Itoa(payer.ID) == id {
+			return &s.fixtures.Payers[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) getPayer(id string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		payer, ok := s.payer(id)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Payer not found"), nil
+		}
+		return http.StatusOK, payer, nil
+	}
+}
+
+func (s *Server) getPayerRates(id string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		payer, ok := s.payer(id)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Payer not found"), nil
+		}
+		return http.StatusOK, s.fixtures.Rates[payer.ID], nil
+	}
+}
+
+// creditParty decodes the credit party identifier of the request and returns the key of the account in the fixtures.
+func creditParty(r *http.Request) (string, bool) {
+	var req pkg.CreditPartyIdentifierRequestWrapper
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return "", false
+	}
+
+	id := req.CreditPartyIdentifier
+	for _, key := range []string{id.MSISDN, id.IBAN, id.BankAccountNumber, id.AccountNumber, id.CLABE, id.CBU} {
+		if key != "" {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func (s *Server) creditPartyInformation(id, transactionType string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		if _, ok := s.payer(id); !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Payer not found"), nil
+		}
+		typ, err := pkg.ParseTransactionType(transactionType)
+		if err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid transaction type"), nil
+		}
+
+		account, ok := creditParty(r)
+		if !ok {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid credit party identifier"), nil
+		}
+
+		// businesses are looked up for transactions to a business
+		if typ.IsBusinessReceiver() {
+			if business, ok := s.fixtures.ReceivingBusinesses[account]; ok {
+				return http.StatusOK, business, nil
+			}
+		} else if beneficiary, ok := s.fixtures.Beneficiaries[account]; ok {
+			return http.StatusOK, beneficiary, nil
+		}
+
+		return http.StatusNotFound, apiError(codeNotFound, "Credit party not found"), nil
+	}
+}
+
+func (s *Server) creditPartyVerification(id, transactionType string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		payer, ok := s.payer(id)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Payer not found"), nil
+		}
+		if _, err := pkg.ParseTransactionType(transactionType); err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid transaction type"), nil
+		}
+
+		account, ok := creditParty(r)
+		if !ok {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid credit party identifier"), nil
+		}
+
+		status, ok := s.fixtures.AccountStatuses[account]
+		if !ok {
+			status = pkg.AccountAvailable
+		}
+
+		return http.StatusOK, pkg.VerificationStatus{ID: payer.ID, AccountStatus: status}, nil
+	}
+}
+
+func (s *Server) createQuotation(r *http.Request) (int, interface{}, http.Header) {
+	var req pkg.CreateQuotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return http.StatusBadRequest, apiError(codeBadRequest, "Invalid JSON"), nil
+	}
+
+	if req.ExternalID == "" {
+		return http.StatusBadRequest, apiError(codeBadRequest, "external_id is mandatory"), nil
+	}
+	if req.Mode.Validate() != nil || req.TransactionType.Validate() != nil {
+		return http.StatusBadRequest, apiError(codeBadRequest, "Invalid mode or transaction type"), nil
+	}
+	if _, ok := s.quotationIDs[req.ExternalID]; ok {
+		return http.StatusBadRequest, apiError(api.CodeDuplicateQuotationID, "External ID already used"), nil
+	}
+
+	payer, ok := s.payer(req.PayerID)
+	if !ok {
+		return http.StatusNotFound, apiError(codeNotFound, "Payer not found"), nil
+	}
+	if _, ok := payer.TransactionTypes[req.TransactionType]; !ok && len(payer.TransactionTypes) > 0 {
+		return http.StatusBadRequest, apiError(codeBadRequest, "Transaction type not supported by payer"), nil
+	}
+
+	// convert the fixed side of the quotation with the rate of its tier
+	tiers := s.fixtures.Rates[payer.ID].Rates[req.TransactionType][req.Source.Currency]
+	if len(tiers) == 0 {
+		return http.StatusBadRequest, apiError(codeNoRate, "No rate available"), nil
+	}
+
+	source, destination := req.Source, pkg.Money{Currency: req.Destination.Currency}
+	switch req.Mode {
+	case pkg.SourceAmount:
+		if source.Amount == nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "source amount is mandatory"), nil
+		}
+		rate := tierRate(tiers, *source.Amount)
+		destination = source.Money().Convert(rate, destination.Currency)
+	case pkg.DestinationAmount:
+		if req.Destination.Amount == nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "destination amount is mandatory"), nil
+		}
+		destination.Amount = *req.Destination.Amount
+
+		// the source amount is rounded up so that it always covers the destination amount
+		units := pkg.MinorUnits(source.Currency)
+		rate := tierRate(tiers, destination.Amount.Div(tiers[0].WholesaleFXRate, units))
+		amount := destination.Amount.Div(rate, units)
+		if amount.Mul(rate).Cmp(destination.Amount) < 0 {
+			amount = amount.Add(pkg.NewDecimal(1, units))
+		}
+		source.Amount = &amount
+	default:
+		return http.StatusBadRequest, apiError(codeBadRequest, "Invalid mode"), nil
+	}
+
+	if destination.Amount.Cmp(payer.MinTransactionAmount) < 0 ||
+		(payer.MaxTransactionAmount.Sign() > 0 && destination.Amount.Cmp(payer.MaxTransactionAmount) > 0) {
+		return http.StatusBadRequest, apiError(codeBadRequest, "Amount out of the payer's limits"), nil
+	}
+
+	fee := pkg.NewMoney(s.fixtures.Fees[payer.ID], source.Currency)
+	sent, _ := source.Money().Add(fee)
+	now := s.now().UTC().Truncate(time.Second)
+
+	s.nextID++
+	quotation := &pkg.Quotation{
+		ID:              s.nextID,
+		ExternalID:      req.ExternalID,
+		Payer:           *payer,
+		Mode:            req.Mode,
+		TransactionType: req.TransactionType,
+		Source:          source,
+		Destination:     destination,
+		SentAmount:      sent,
+		WholeSaleFXRate: tierRate(tiers, *source.Amount),
+		Fee:             fee,
+		CreationDate:    pkg.Timestamp{Time: now},
+		ExpirationDate:  pkg.Timestamp{Time: now.Add(s.quotationTTL)},
+	}
+	s.quotations[quotation.ID] = quotation
+	s.quotationIDs[quotation.ExternalID] = quotation.ID
+
+	return http.StatusCreated, quotation, nil
+}
+
+// tierRate returns the rate of the tier the source amount falls in, or of the closest tier.
+func tierRate(tiers []pkg.Rates, amount pkg.Decimal) pkg.Decimal {
+	for _, tier := range tiers {
+		if amount.Cmp(tier.SourceAmountMin) >= 0 && amount.Cmp(tier.SourceAmountMax) < 0 {
+			return tier.WholesaleFXRate
+		}
+	}
+
+	if amount.Cmp(tiers[0].SourceAmountMin) < 0 {
+		return tiers[0].WholesaleFXRate
+	}
+	return tiers[len(tiers)-1].WholesaleFXRate
+}
+
+// quotation returns the quotation identified by an id or an "ext-" prefixed external id.
+func (s *Server) quotation(ref string) (*pkg.Quotation, bool) {
+	id, ok := resolve(ref, s.quotationIDs)
+	if !ok {
+		return nil, false
+	}
+	quotation, ok := s.quotations[id]
+	return quotation, ok
+}
+
+// resolve converts an id or an "ext-" prefixed external id from a url into an id.
+func resolve(ref string, externalIDs map[string]int) (int, bool) {
+	if strings.HasPrefix(ref, "ext-") {
+		id, ok := externalIDs[strings.TrimPrefix(ref, "ext-")]
+		return id, ok
+	}
+
+	id, err := strconv.Atoi(ref)
+	return id, err == nil
+}
+
+func (s *Server) getQuotation(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		quotation, ok := s.quotation(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Quotation not found"), nil
+		}
+		return http.StatusOK, quotation, nil
+	}
+}
+
+func (s *Server) createTransaction(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		quotation, ok := s.quotation(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Quotation not found"), nil
+		}
+
+		if !s.now().Before(quotation.ExpirationDate.Time) {
+			return http.StatusBadRequest, apiError(api.CodeQuotationExpired, "Quotation expired"), nil
+		}
+
+		var req pkg.CreateTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid JSON"), nil
+		}
+
+		if req.ExternalID == nil || *req.ExternalID == "" {
+			return http.StatusBadRequest, apiError(codeBadRequest, "external_id is mandatory"), nil
+		}
+		if _, ok := s.transactionIDs[*req.ExternalID]; ok {
+			return http.StatusBadRequest, apiError(api.CodeDuplicateTransactionID, "External ID already used"), nil
+		}
+		if req.CreditPartyIdentifier == nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "credit_party_identifier is mandatory"), nil
+		}
+
+		// the payer rejects the transactions missing the fields it requires
+		if err := pkg.Validate(&quotation.Payer, quotation.TransactionType, &req); err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, err.Error()), nil
+		}
+
+		// the transaction gets its own copy of the quotation's amounts
+		source, destination, sent, fee, rate := quotation.Source, quotation.Destination, quotation.SentAmount, quotation.Fee, quotation.WholeSaleFXRate
+		payer := quotation.Payer
+
+		now := s.now().UTC()
+		s.nextID++
+		transaction := &pkg.Transaction{
+			ID:                      intPtr(s.nextID),
+			ExternalID:              req.ExternalID,
+			ExternalCode:            req.ExternalCode,
+			TransactionType:         &quotation.TransactionType,
+			CreationDate:            &pkg.Timestamp{Time: now},
+			ExpirationDate:          &pkg.Timestamp{Time: now.Add(s.quotationTTL)},
+			CreditPartyIdentifier:   req.CreditPartyIdentifier,
+			Source:                  &source,
+			Destination:             &destination,
+			Payer:                   &payer,
+			Sender:                  req.Sender,
+			Beneficiary:             req.Beneficiary,
+			SendingBusiness:         req.SendingBusiness,
+			ReceivingBusiness:       req.ReceivingBusiness,
+			CallbackURL:             req.CallbackURL,
+			SendAmount:              &sent,
+			WholeSaleFXRate:         &rate,
+			RetailRate:              req.RetailRate,
+			RetailFee:               req.RetailFee,
+			RetailFeeCurrency:       req.RetailFeeCurrency,
+			Fee:                     &fee,
+			PurposeOfRemittance:     req.PurposeOfRemittance,
+			DocumentReferenceNumber: req.DocumentReferenceNumber,
+			AdditionalInformation1:  req.AdditionalInformation1,
+			AdditionalInformation2:  req.AdditionalInformation2,
+			AdditionalInformation3:  req.AdditionalInformation3,
+		}
+		s.transition(transaction, pkg.StatusCreated)
+
+		s.transactions[*transaction.ID] = transaction
+		s.transactionIDs[*transaction.ExternalID] = *transaction.ID
+
+		return http.StatusCreated, transaction, nil
+	}
+}
+
+// transaction returns the transaction identified by an id or an "ext-" prefixed external id.
+func (s *Server) transaction(ref string) (*pkg.Transaction, bool) {
+	id, ok := resolve(ref, s.transactionIDs)
+	if !ok {
+		return nil, false
+	}
+	transaction, ok := s.transactions[id]
+	return transaction, ok
+}
+
+func (s *Server) getTransaction(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		transaction, ok := s.transaction(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Transaction not found"), nil
+		}
+		return http.StatusOK, transaction, nil
+	}
+}
+
+func (s *Server) confirmTransaction(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		transaction, ok := s.transaction(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Transaction not found"), nil
+		}
+		if *transaction.Status != pkg.StatusCreated {
+			return http.StatusBadRequest, apiError(codeInvalidStatus, "Transaction can not be confirmed"), nil
+		}
+
+		// the sent amount, fee included, is taken from the balance of the source currency
+		sent := *transaction.SendAmount
+		balance := s.balance(sent.Currency)
+		if balance == nil || balance.Available.Cmp(sent.Amount) < 0 {
+			return http.StatusBadRequest, apiError(api.CodeInsufficientBalance, "Insufficient balance"), nil
+		}
+		balance.Balance = balance.Balance.Sub(sent.Amount)
+		balance.Available = balance.Available.Sub(sent.Amount)
+		s.transactionPayments[*transaction.ID] = sent
+
+		// cash pickups wait for the beneficiary, everything else is processed right away
+		if transaction.Payer != nil && transaction.Payer.Service.Name == "CashPickup" {
+			s.transition(transaction, pkg.StatusConfirmedWaitingForPickup)
+		} else {
+			s.transition(transaction, pkg.StatusConfirmed)
+		}
+
+		return http.StatusOK, transaction, nil
+	}
+}
+
+func (s *Server) cancelTransaction(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		transaction, ok := s.transaction(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Transaction not found"), nil
+		}
+
+		switch *transaction.Status {
+		case pkg.StatusCreated:
+		case pkg.StatusConfirmedWaitingForPickup:
+			s.refund(*transaction.ID)
+		default:
+			return http.StatusBadRequest, apiError(api.CodeTransactionCannotBeCanceled, "Transaction can not be cancelled"), nil
+		}
+
+		s.transition(transaction, pkg.StatusCancelled)
+		return http.StatusOK, transaction, nil
+	}
+}
+
+func (s *Server) listAttachments(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		transaction, ok := s.transaction(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Transaction not found"), nil
+		}
+		return http.StatusOK, append([]pkg.TransactionAttachment{}, s.attachments[*transaction.ID]...), nil
+	}
+}
+
+func (s *Server) addAttachment(ref string) endpoint {
+	return func(r *http.Request) (int, interface{}, http.Header) {
+		transaction, ok := s.transaction(ref)
+		if !ok {
+			return http.StatusNotFound, apiError(codeNotFound, "Transaction not found"), nil
+		}
+		if len(s.attachments[*transaction.ID]) >= maxAttachments {
+			return http.StatusBadRequest, apiError(codeTooManyAttached, "Maximum number of attachments reached"), nil
+		}
+
+		typ, err := pkg.ParseTransactionAttachmentType(r.FormValue("type"))
+		if err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid attachment type"), nil
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "file is mandatory"), nil
+		}
+		defer file.Close()
+
+		if _, err := ioutil.ReadAll(file); err != nil {
+			return http.StatusBadRequest, apiError(codeBadRequest, "Invalid file"), nil
+		}
+
+		s.nextID++
+		attachment := pkg.TransactionAttachment{
+			ID:            s.nextID,
+			TransactionID: *transaction.ID,
+			Name:          header.Filename,
+			ContentType:   header.Header.Get("Content-Type"),
+			Type:          typ,
+		}
+		s.attachments[*transaction.ID] = append(s.attachments[*transaction.ID], attachment)
+
+		return http.StatusOK, attachment, nil
+	}
+}
+
+// balance returns the balance of the currency.
+func (s *Server) balance(currency string) *pkg.Balance {
+	for i := range s.fixtures.Balances {
+		if s.fixtures.Balances[i].Currency == currency {
+			return &s.fixtures.Balances[i]
+		}
+	}
+	return nil
+}
+
+// refund gives back the amount taken from the balance when the transaction was confirmed.
+func (s *Server) refund(id int) {
+	sent, ok := s.transactionPayments[id]
+	if !ok {
+		return
+	}
+	delete(s.transactionPayments, id)
+
+	if balance := s.balance(sent.Currency); balance != nil {
+		balance.Balance = balance.Balance.Add(sent.Amount)
+		balance.Available = balance.Available.Add(sent.Amount)
+	}
+}
+
+// transition moves the transaction to the status and delivers a callback to its callback url, if any.
+// It must be called with s.mu held.
+func (s *Server) transition(transaction *pkg.Transaction, status pkg.TransactionStatus) {
+	setStatus(transaction, status)
+
+	if transaction.CallbackURL == nil || *transaction.CallbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(transaction)
+	if err != nil {
+		return
+	}
+
+	// callbacks are delivered in the background, as Thunes does
+	s.callbacks.Add(1)
+	go func(url string) {
+		defer s.callbacks.Done()
+
+		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}(*transaction.CallbackURL)
+}
+
+// WaitCallbacks waits until every callback triggered so far has been delivered.
+func (s *Server) WaitCallbacks() {
+	s.callbacks.Wait()
+}
+
+func setStatus(transaction *pkg.Transaction, status pkg.TransactionStatus) {
+	class := status.Class()
+	transaction.Status = &status
+	transaction.StatusMessage = strPtr(statusMessages[status])
+	transaction.StatusClass = &class
+	transaction.StatusClassMessage = strPtr(class.String())
+}
+
+// SetTransactionStatus moves a transaction to the given status, as the payer would while processing it.
+// Moving a paid transaction to a cancelled, declined, rejected or reversed status refunds the balance.
+func (s *Server) SetTransactionStatus(id int, status pkg.TransactionStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[id]
+	if !ok {
+		return fmt.Errorf("thunestest: transaction %d not found", id)
+	}
+	if _, ok := statusMessages[status]; !ok {
+		return fmt.Errorf("thunestest: unknown status %q", status)
+	}
+
+	switch status {
+	case pkg.StatusCancelled, pkg.StatusDeclined, pkg.StatusRejected, pkg.StatusReversed:
+		s.refund(id)
+	}
+
+	s.transition(transaction, status)
+	return nil
+}
+
+// Transaction returns a copy of the transaction with the given id.
+func (s *Server) Transaction(id int) (pkg.Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[id]
+	if !ok {
+		return pkg.Transaction{}, false
+	}
+	return *transaction, true
+}
+
+// Quotation returns a copy of the quotation with the given id.
+func (s *Server) Quotation(id int) (pkg.Quotation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quotation, ok := s.quotations[id]
+	if !ok {
+		return pkg.Quotation{}, false
+	}
+	return *quotation, true
+}
+
+// Attachments returns the attachments of the transaction with the given id.
+func (s *Server) Attachments(id int) []pkg.TransactionAttachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]pkg.TransactionAttachment(nil), s.attachments[id]...)
+}
+
+// Balances returns the current balances.
+func (s *Server) Balances() []pkg.Balance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]pkg.Balance(nil), s.fixtures.Balances...)
+}
@@ -0,0 +1,318 @@
+// Package thunestest provides an in-process fake of the Thunes money transfer API for tests.
+//
+// A Server keeps quotations, transactions, attachments and balances in memory and moves
+// transactions through the same statuses as the real API, delivering status callbacks to
+// their callback url, so that payout flows can be exercised offline:
+//
+//	srv := thunestest.NewServer()
+//	defer srv.Close()
+//
+//	tc := srv.Client()
+//	status, err := tc.Ping(ctx)
+package thunestest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"thunes-client/api"
+	"thunes-client/pkg"
+)
+
+const (
+	// APIKey is the API key accepted by a Server unless WithCredentials is used.
+	APIKey = "thunestest-key"
+	// APISecret is the API secret accepted by a Server unless WithCredentials is used.
+	APISecret = "thunestest-secret"
+)
+
+// Server is a fake Thunes API served over HTTP.
+// It is safe for concurrent use, and its state can be inspected and driven by the test.
+type Server struct {
+	*httptest.Server
+
+	apiKey       string
+	apiSecret    string
+	quotationTTL time.Duration
+	now          func() time.Time
+
+	mu                  sync.Mutex
+	fixtures            Fixtures
+	nextID              int
+	quotations          map[int]*pkg.Quotation
+	quotationIDs        map[string]int
+	transactions        map[int]*pkg.Transaction
+	transactionIDs      map[string]int
+	transactionPayments map[int]pkg.Money
+	attachments         map[int][]pkg.TransactionAttachment
+	faults              []*Fault
+	requests            []Request
+
+	callbacks sync.WaitGroup
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithFixtures serves the given fixtures instead of DefaultFixtures.
+func WithFixtures(fixtures Fixtures) Option {
+	return func(s *Server) {
+		s.fixtures = fixtures
+	}
+}
+
+// WithCredentials sets the API key and secret the server accepts.
+func WithCredentials(apiKey, apiSecret string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+		s.apiSecret = apiSecret
+	}
+}
+
+// WithQuotationTTL sets how long quotations remain valid, it defaults to one hour.
+func WithQuotationTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.quotationTTL = ttl
+	}
+}
+
+// WithClock makes the server read the current time from now, e.g. to expire quotations on demand.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+}
+
+// NewServer starts a fake Thunes API, it must be closed with Close.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		apiKey:              APIKey,
+		apiSecret:           APISecret,
+		quotationTTL:        time.Hour,
+		now:                 time.Now,
+		fixtures:            DefaultFixtures(),
+		quotations:          make(map[int]*pkg.Quotation),
+		quotationIDs:        make(map[string]int),
+		transactions:        make(map[int]*pkg.Transaction),
+		transactionIDs:      make(map[string]int),
+		transactionPayments: make(map[int]pkg.Money),
+		attachments:         make(map[int][]pkg.TransactionAttachment),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a ThunesClient pointed at the server with the server's credentials.
+// The options are applied after the base url, credentials are not overridable.
+func (s *Server) Client(opts ...api.Option) *api.ThunesClient {
+	opts = append([]api.Option{api.WithBaseURL(s.URL)}, opts...)
+	return api.NewThunesClient(s.apiKey, s.apiSecret, opts...)
+}
+
+// Close waits for the callbacks in flight and shuts the server down.
+func (s *Server) Close() {
+	s.WaitCallbacks()
+	s.Server.Close()
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path})
+	fault := s.matchFault(r)
+	s.mu.Unlock()
+
+	if fault != nil && fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	// a fault without status code only delays the request
+	if fault != nil && fault.StatusCode != 0 {
+		if fault.AfterHandling {
+			// handle the request for real and throw the response away, as if it was lost on its way back
+			s.route(httptest.NewRecorder(), r)
+		}
+		fault.write(w)
+		return
+	}
+
+	s.route(w, r)
+}
+
+// route dispatches the request to its endpoint handler.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != s.apiKey || pass != s.apiSecret {
+		writeError(w, http.StatusUnauthorized, api.CodeInvalidCredentials, "Unauthorized")
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) == 1 && path[0] == "ping" {
+		s.handle(w, r, http.MethodGet, s.ping)
+		return
+	}
+
+	if len(path) < 3 || path[0] != "v2" || path[1] != "money-transfer" {
+		writeError(w, http.StatusNotFound, "1000404", "Not found")
+		return
+	}
+
+	path = path[2:]
+	switch {
+	case match(path, "services"):
+		s.handle(w, r, http.MethodGet, s.listServices)
+	case match(path, "countries"):
+		s.handle(w, r, http.MethodGet, s.listCountries)
+	case match(path, "balances"):
+		s.handle(w, r, http.MethodGet, s.listBalances)
+	case match(path, "lookups", "BIC", "*"):
+		s.handle(w, r, http.MethodGet, s.lookupBIC(path[2]))
+	case match(path, "payers"):
+		s.handle(w, r, http.MethodGet, s.listPayers)
+	case match(path, "payers", "*"):
+		s.handle(w, r, http.MethodGet, s.getPayer(path[1]))
+	case match(path, "payers", "*", "rates"):
+		s.handle(w, r, http.MethodGet, s.getPayerRates(path[1]))
+	case match(path, "payers", "*", "*", "credit-party-information"):
+		s.handle(w, r, http.MethodPost, s.creditPartyInformation(path[1], path[2]))
+	case match(path, "payers", "*", "*", "credit-party-verification"):
+		s.handle(w, r, http.MethodPost, s.creditPartyVerification(path[1], path[2]))
+	case match(path, "quotations"):
+		s.handle(w, r, http.MethodPost, s.createQuotation)
+	case match(path, "quotations", "*"):
+		s.handle(w, r, http.MethodGet, s.getQuotation(path[1]))
+	case match(path, "quotations", "*", "transactions"):
+		s.handle(w, r, http.MethodPost, s.createTransaction(path[1]))
+	case match(path, "transactions", "*"):
+		s.handle(w, r, http.MethodGet, s.getTransaction(path[1]))
+	case match(path, "transactions", "*", "confirm"):
+		s.handle(w, r, http.MethodPost, s.confirmTransaction(path[1]))
+	case match(path, "transactions", "*", "cancel"):
+		s.handle(w, r, http.MethodPost, s.cancelTransaction(path[1]))
+	case match(path, "transactions", "*", "attachments"):
+		if r.Method == http.MethodGet {
+			s.handle(w, r, http.MethodGet, s.listAttachments(path[1]))
+		} else {
+			s.handle(w, r, http.MethodPost, s.addAttachment(path[1]))
+		}
+	default:
+		writeError(w, http.StatusNotFound, "1000404", "Not found")
+	}
+}
+
+// endpoint handles a request under the server lock and returns the status code and body of the response.
+type endpoint func(r *http.Request) (int, interface{}, http.Header)
+
+// handle checks the method and runs the endpoint under the server lock.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, method string, fn endpoint) {
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, "1000405", "Method not allowed")
+		return
+	}
+
+	// the body may point into the server state, encode it before releasing the lock
+	s.mu.Lock()
+	status, body, header := fn(r)
+	data, err := json.Marshal(body)
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "1000500", err.Error())
+		return
+	}
+
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+
+	writeJSON(w, status, json.RawMessage(data))
+}
+
+// match reports whether the path segments match the pattern, "*" matches any segment.
+func match(path []string, pattern ...string) bool {
+	if len(path) != len(pattern) {
+		return false
+	}
+
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// paginate returns the window of n items selected by the page and per_page query params,
+// and the headers describing the pagination.
+func paginate(r *http.Request, n int) (int, int, http.Header) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 || perPage > api.MaxPerPage {
+		perPage = 50
+	}
+
+	from := (page - 1) * perPage
+	if from > n {
+		from = n
+	}
+	to := from + perPage
+	if to > n {
+		to = n
+	}
+
+	header := http.Header{}
+	header.Set("X-Total-Count", strconv.Itoa(n))
+	header.Set("X-Page", strconv.Itoa(page))
+	header.Set("X-Per-Page", strconv.Itoa(perPage))
+
+	return from, to, header
+}
+
+// apiError builds the response body of an error.
+func apiError(code, message string) *pkg.Errors {
+	return &pkg.Errors{Errors: []pkg.Error{{Code: code, Message: message}}}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiError(code, message))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		panic(fmt.Sprintf("thunestest: encoding response: %v", err))
+	}
+}